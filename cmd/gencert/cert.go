@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// certConfig contains the configuration for [newSelfSignedCert].
+type certConfig struct {
+	CommonName   string
+	DNSNames     []string
+	ExpireAfter  time.Duration
+	IPAddrs      []net.IP
+	KeyType      string
+	Organization []string
+}
+
+// errInvalidKeyType indicates that the key type is not supported.
+var errInvalidKeyType = errors.New("invalid key type")
+
+// newPrivateKey generates a private key of the given type.
+func newPrivateKey(keyType string) (crypto.Signer, error) {
+	switch keyType {
+	case "rsa2048":
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case "rsa4096":
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case "ecdsa-p256":
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ed25519":
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		return priv, err
+	default:
+		return nil, fmt.Errorf("%w: %s", errInvalidKeyType, keyType)
+	}
+}
+
+// certKeyType returns the key type of the given certificate.
+func certKeyType(cert *x509.Certificate) string {
+	switch pub := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("rsa%d", pub.N.BitLen())
+	case *ecdsa.PublicKey:
+		if pub.Curve == elliptic.P256() {
+			return "ecdsa-p256"
+		}
+	case ed25519.PublicKey:
+		return "ed25519"
+	}
+	return ""
+}
+
+// newSelfSignedCert generates a self-signed certificate and returns
+// the PEM-encoded certificate and PKCS#8 private key.
+func newSelfSignedCert(config *certConfig) (certPEM, keyPEM []byte, err error) {
+	priv, err := newPrivateKey(config.KeyType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Only RSA keys are used for key encipherment (i.e., RSA key exchange).
+	keyUsage := x509.KeyUsageDigitalSignature
+	if _, ok := priv.(*rsa.PrivateKey); ok {
+		keyUsage |= x509.KeyUsageKeyEncipherment
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	notBefore := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			Organization: config.Organization,
+			CommonName:   config.CommonName,
+		},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(config.ExpireAfter),
+		KeyUsage:              keyUsage,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              config.DNSNames,
+		IPAddresses:           config.IPAddrs,
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, priv.Public(), priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+// ocspValidity is how long stub OCSP responses are valid, which is
+// in the range of what public CAs use.
+const ocspValidity = 7 * 24 * time.Hour
+
+// newOCSPStaple returns a DER-encoded OCSP response declaring the given
+// self-signed certificate good, signed by the certificate itself.
+//
+// Clients cannot validate the response, since the certificate is not
+// an OCSP responder for itself, but the response has a realistic size,
+// which suffices to measure the handshake cost of stapling.
+func newOCSPStaple(certPEM, keyPEM []byte) ([]byte, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := cert.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, errInvalidKeyType
+	}
+	now := time.Now()
+	template := ocsp.Response{
+		Status:       ocsp.Good,
+		SerialNumber: cert.Leaf.SerialNumber,
+		ThisUpdate:   now,
+		NextUpdate:   now.Add(ocspValidity),
+	}
+	return ocsp.CreateResponse(cert.Leaf, cert.Leaf, template, signer)
+}
+
+// writeCertFiles writes certPEM to `cert.pem` and keyPEM to `key.pem`.
+func writeCertFiles(baseDir string, certPEM, keyPEM []byte) error {
+	if err := os.WriteFile(filepath.Join(baseDir, "cert.pem"), certPEM, 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(baseDir, "key.pem"), keyPEM, 0600)
+}
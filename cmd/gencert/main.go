@@ -10,9 +10,9 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
-	"github.com/bassosimone/pkitest"
 	"github.com/bassosimone/runtimex"
 	"github.com/bassosimone/vclip"
 	"github.com/bassosimone/vflag"
@@ -24,46 +24,91 @@ func main() {
 
 func run(ctx context.Context, args []string) error {
 	var (
+		dnsNames  = []string{}
+		ipAddrs   = []string{}
+		keyType   = "ecdsa-p256"
+		ocsp      = false
 		outputDir = "./testdata"
-		ipAddr    = "127.0.0.1"
+		validity  = 365 * 24 * time.Hour
 	)
 
 	fset := vflag.NewFlagSet("gencert", vflag.ExitOnError)
+	fset.StringSliceVar(&dnsNames, 0, "dns-name", "Use `NAME` as a DNS SAN (repeatable).")
 	fset.AutoHelp('h', "help", "Print this help text and exit.")
-	fset.StringVar(&ipAddr, 0, "ip-addr", "Use `ADDR` as an IP SAN.")
+	fset.StringSliceVar(&ipAddrs, 0, "ip-addr", "Use `ADDR` as an IP SAN (repeatable; default: 127.0.0.1).")
+	fset.StringVar(&keyType, 0, "key-type", "Generate a `TYPE` key (rsa2048, rsa4096, ecdsa-p256, or ed25519).")
+	fset.BoolVar(&ocsp, 0, "ocsp", "Also write a stub OCSP response for the certificate to ocsp.der.")
 	fset.StringVar(&outputDir, 'o', "output-dir", "Write certificates to `DIR`.")
+	fset.DurationVar(&validity, 0, "validity", "Make certificates valid for `DURATION` (default: @DEFAULT_VALUE@).")
 	runtimex.PanicOnError0(fset.Parse(args))
 
-	ip := net.ParseIP(ipAddr)
-	if ip == nil {
-		log.Fatalf("gencert: invalid IP address: %s", ipAddr)
+	if len(ipAddrs) <= 0 && len(dnsNames) <= 0 {
+		ipAddrs = []string{"127.0.0.1"}
+	}
+	ips := make([]net.IP, 0, len(ipAddrs))
+	for _, ipAddr := range ipAddrs {
+		ip := net.ParseIP(ipAddr)
+		if ip == nil {
+			log.Fatalf("gencert: invalid IP address: %s", ipAddr)
+		}
+		ips = append(ips, ip)
+	}
+	if validity <= 0 {
+		log.Fatalf("gencert: invalid validity: %s", validity)
+	}
+	switch keyType {
+	case "rsa2048", "rsa4096", "ecdsa-p256", "ed25519":
+	default:
+		log.Fatalf("gencert: invalid key type: %s", keyType)
 	}
 
-	// Check whether existing certificates are still valid for this IP.
+	// Check whether existing certificates are still valid for these SANs.
 	certPath := filepath.Join(outputDir, "cert.pem")
-	if existingCertIsValid(certPath, ip) {
+	if existingCertIsValid(certPath, keyType, ips, dnsNames) {
 		log.Printf("gencert: certificates are valid, nothing to do")
+		if ocsp {
+			writeOCSPStaple(outputDir)
+		}
 		return nil
 	}
 
-	config := &pkitest.SelfSignedCertConfig{
-		CommonName:   ipAddr,
-		DNSNames:     []string{ipAddr},
-		IPAddrs:      []net.IP{ip},
+	// We also list the IP addresses as DNS names, as we always did.
+	names := slices.Concat(ipAddrs, dnsNames)
+	config := &certConfig{
+		CommonName:   names[0],
+		DNSNames:     names,
+		ExpireAfter:  validity,
+		IPAddrs:      ips,
+		KeyType:      keyType,
 		Organization: []string{"ocho"},
 	}
+	certPEM, keyPEM := runtimex.LogFatalOnError2(newSelfSignedCert(config))
 
 	runtimex.LogFatalOnError0(os.MkdirAll(outputDir, 0700))
-	pkitest.MustNewSelfSignedCert(config).MustWriteFiles(outputDir)
+	runtimex.LogFatalOnError0(writeCertFiles(outputDir, certPEM, keyPEM))
 
 	log.Printf("gencert: wrote %s", filepath.Join(outputDir, "cert.pem"))
 	log.Printf("gencert: wrote %s", filepath.Join(outputDir, "key.pem"))
+	if ocsp {
+		writeOCSPStaple(outputDir)
+	}
 	return nil
 }
 
-// existingCertIsValid returns true if the cert at certPath exists,
-// does not expire within 30 days, and contains the given IP SAN.
-func existingCertIsValid(certPath string, wantIP net.IP) bool {
+// writeOCSPStaple writes a fresh OCSP response for the certificate in
+// outputDir, since the response expires well before the certificate.
+func writeOCSPStaple(outputDir string) {
+	certPEM := runtimex.LogFatalOnError1(os.ReadFile(filepath.Join(outputDir, "cert.pem")))
+	keyPEM := runtimex.LogFatalOnError1(os.ReadFile(filepath.Join(outputDir, "key.pem")))
+	ocspDER := runtimex.LogFatalOnError1(newOCSPStaple(certPEM, keyPEM))
+	ocspPath := filepath.Join(outputDir, "ocsp.der")
+	runtimex.LogFatalOnError0(os.WriteFile(ocspPath, ocspDER, 0600))
+	log.Printf("gencert: wrote %s", ocspPath)
+}
+
+// existingCertIsValid returns true if the cert at certPath exists, does not
+// expire within 30 days, uses keyType, and contains all the given SANs.
+func existingCertIsValid(certPath, keyType string, wantIPs []net.IP, wantDNSNames []string) bool {
 	data, err := os.ReadFile(certPath)
 	if err != nil {
 		return false
@@ -79,10 +124,18 @@ func existingCertIsValid(certPath string, wantIP net.IP) bool {
 	if time.Until(cert.NotAfter) < 30*24*time.Hour {
 		return false
 	}
-	for _, ip := range cert.IPAddresses {
-		if ip.Equal(wantIP) {
-			return true
+	if certKeyType(cert) != keyType {
+		return false
+	}
+	for _, wantIP := range wantIPs {
+		if !slices.ContainsFunc(cert.IPAddresses, wantIP.Equal) {
+			return false
+		}
+	}
+	for _, wantDNSName := range wantDNSNames {
+		if !slices.Contains(cert.DNSNames, wantDNSName) {
+			return false
 		}
 	}
-	return false
+	return true
 }
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// accessLog writes an NCSA Common Log Format access log with the request duration.
+type accessLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// openAccessLog opens the access log at path for appending or
+// returns an access log writing to stdout when path is "-".
+func openAccessLog(path string) (*accessLog, error) {
+	if path == "-" {
+		return &accessLog{w: os.Stdout}, nil
+	}
+	filep, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &accessLog{w: filep}, nil
+}
+
+// wrap returns a handler logging each request served by next.
+func (al *accessLog) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		t0 := time.Now()
+		lrw := &loggingResponseWriter{ResponseWriter: rw}
+		next.ServeHTTP(lrw, req)
+		al.write(req, lrw, t0, time.Since(t0))
+	})
+}
+
+// write writes a log line for the given request.
+func (al *accessLog) write(req *http.Request, lrw *loggingResponseWriter, t0 time.Time, elapsed time.Duration) {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	status := lrw.status
+	if status == 0 {
+		status = http.StatusOK // net/http default when the handler writes nothing
+	}
+	size := "-"
+	if lrw.count > 0 {
+		size = strconv.FormatInt(lrw.count, 10)
+	}
+	line := fmt.Sprintf("%s - - [%s] \"%s %s %s\" %d %s %d\n",
+		host, t0.Format("02/Jan/2006:15:04:05 -0700"), req.Method, req.RequestURI,
+		req.Proto, status, size, elapsed.Microseconds())
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	io.WriteString(al.w, line)
+}
+
+// loggingResponseWriter records the status code and body size.
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	count  int64
+	status int
+}
+
+var _ io.ReaderFrom = &loggingResponseWriter{}
+
+// WriteHeader implements [http.ResponseWriter].
+func (lrw *loggingResponseWriter) WriteHeader(status int) {
+	if lrw.status == 0 {
+		lrw.status = status
+	}
+	lrw.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements [http.ResponseWriter].
+func (lrw *loggingResponseWriter) Write(data []byte) (int, error) {
+	if lrw.status == 0 {
+		lrw.status = http.StatusOK
+	}
+	count, err := lrw.ResponseWriter.Write(data)
+	lrw.count += int64(count)
+	return count, err
+}
+
+// ReadFrom implements [io.ReaderFrom], such that net/http can still use
+// sendfile when the response body is a file (e.g., with --serve-file).
+func (lrw *loggingResponseWriter) ReadFrom(r io.Reader) (int64, error) {
+	if lrw.status == 0 {
+		lrw.status = http.StatusOK
+	}
+	var (
+		count int64
+		err   error
+	)
+	if rf, ok := lrw.ResponseWriter.(io.ReaderFrom); ok {
+		count, err = rf.ReadFrom(r)
+	} else {
+		count, err = io.Copy(struct{ io.Writer }{lrw.ResponseWriter}, r)
+	}
+	lrw.count += count
+	return count, err
+}
+
+// Unwrap allows [http.ResponseController] to reach the underlying writer.
+func (lrw *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return lrw.ResponseWriter
+}
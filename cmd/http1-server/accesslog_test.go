@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAccessLogReadFrom(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "body")
+	body := bytes.Repeat([]byte("0123456789"), 1000)
+	if err := os.WriteFile(path, body, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var logbuf bytes.Buffer
+	al := &accessLog{w: &logbuf}
+	handler := al.wrap(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if _, ok := rw.(io.ReaderFrom); !ok {
+			t.Error("the access log hides io.ReaderFrom")
+		}
+		filep, err := os.Open(path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer filep.Close()
+		io.Copy(rw, io.LimitReader(filep, int64(len(body))))
+	}))
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, body) {
+		t.Fatal("unexpected body")
+	}
+	srv.Close() // wait for the handler to write the log line
+	if line := logbuf.String(); !strings.Contains(line, "\" 200 10000 ") {
+		t.Fatalf("unexpected log line: %q", line)
+	}
+}
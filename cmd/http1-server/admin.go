@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// adminDrain implements the POST /admin/drain endpoint.
+type adminDrain struct {
+	// drain starts draining the servers and must not block.
+	drain func()
+
+	// token is the bearer token required to authenticate.
+	token string
+}
+
+// handleDrain handles POST /admin/drain by starting an orchestrated shutdown.
+func (ad *adminDrain) handleDrain(rw http.ResponseWriter, req *http.Request) {
+	token, found := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !found || subtle.ConstantTimeCompare([]byte(token), []byte(ad.token)) != 1 {
+		slog.Warn("admin drain unauthorized", slog.String("remote", req.RemoteAddr))
+		rw.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	slog.Info("admin drain requested", slog.String("remote", req.RemoteAddr))
+	ad.drain()
+	rw.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,237 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"golang.org/x/time/rate"
+)
+
+// limitListener is a [net.Listener] accepting at most a fixed number
+// of concurrent connections, like golang.org/x/net/netutil.LimitListener.
+type limitListener struct {
+	net.Listener
+	closeOnce sync.Once
+	done      chan struct{}
+	sem       chan struct{}
+}
+
+// newLimitListener returns a [*limitListener] wrapping ln.
+func newLimitListener(ln net.Listener, maxConns int) *limitListener {
+	return &limitListener{Listener: ln, done: make(chan struct{}), sem: make(chan struct{}, maxConns)}
+}
+
+// Accept implements [net.Listener].
+func (ln *limitListener) Accept() (net.Conn, error) {
+	select {
+	case ln.sem <- struct{}{}:
+	default:
+		slog.Warn("max conns reached; waiting for a free slot", slog.Int("maxConns", cap(ln.sem)))
+		select {
+		case ln.sem <- struct{}{}:
+		case <-ln.done:
+			return nil, net.ErrClosed
+		}
+	}
+	conn, err := ln.Listener.Accept()
+	if err != nil {
+		<-ln.sem
+		return nil, err
+	}
+	return &limitListenerConn{Conn: conn, release: func() { <-ln.sem }}, nil
+}
+
+// Close implements [net.Listener].
+func (ln *limitListener) Close() error {
+	err := ln.Listener.Close()
+	ln.closeOnce.Do(func() { close(ln.done) })
+	return err
+}
+
+// limitListenerConn releases its slot when closed.
+type limitListenerConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+// Close implements [net.Conn].
+func (c *limitListenerConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
+
+// SyscallConn implements [syscall.Conn] so that socket options
+// remain accessible through the wrapper.
+func (c *limitListenerConn) SyscallConn() (syscall.RawConn, error) {
+	sc, ok := c.Conn.(syscall.Conn)
+	if !ok {
+		return nil, syscall.EINVAL
+	}
+	return sc.SyscallConn()
+}
+
+// rpsLimiter rejects requests exceeding a rate with 503.
+type rpsLimiter struct {
+	limiter *rate.Limiter
+}
+
+// newRPSLimiter returns a [*rpsLimiter] allowing rps requests per second.
+func newRPSLimiter(rps float64) *rpsLimiter {
+	return &rpsLimiter{limiter: rate.NewLimiter(rate.Limit(rps), max(1, int(rps)))}
+}
+
+// wrap returns a handler that applies the rate limit before calling next.
+func (rl *rpsLimiter) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if !rl.limiter.Allow() {
+			slog.Warn("request rejected", slog.String("reason", "max-rps"),
+				slog.String("method", req.Method), slog.String("path", req.URL.Path),
+				slog.String("remote", req.RemoteAddr))
+			rw.Header().Set("Retry-After", "1")
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(rw, req)
+	})
+}
+
+// workerPool serves requests using a bounded number of workers, like
+// thread-pool-based servers, queueing requests when all are busy.
+type workerPool struct {
+	// queueMax is the maximum number of queued requests or zero.
+	queueMax int64
+
+	// queued is the number of requests waiting for a worker.
+	queued atomic.Int64
+
+	// workers is the semaphore limiting the concurrent requests.
+	workers chan struct{}
+}
+
+// newWorkerPool returns a [*workerPool] with the given number of workers,
+// queueing up to queueMax requests (zero means unlimited).
+func newWorkerPool(workers, queueMax int) *workerPool {
+	return &workerPool{queueMax: int64(queueMax), workers: make(chan struct{}, workers)}
+}
+
+// errQueueFull indicates that the worker pool queue is full.
+var errQueueFull = errors.New("queue full")
+
+// acquire waits for a free worker, failing when the queue
+// is full or ctx is done while waiting.
+func (wp *workerPool) acquire(ctx context.Context) error {
+	select {
+	case wp.workers <- struct{}{}:
+		return nil
+	default:
+	}
+	queued := wp.queued.Add(1)
+	defer wp.queued.Add(-1)
+	if wp.queueMax > 0 && queued > wp.queueMax {
+		return errQueueFull
+	}
+	select {
+	case wp.workers <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// wrap returns a handler that waits for a free worker before calling
+// next and rejects requests with 503 when the queue is full.
+func (wp *workerPool) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		err := wp.acquire(req.Context())
+		if errors.Is(err, errQueueFull) {
+			slog.Warn("request rejected", slog.String("reason", "queue-max"),
+				slog.String("method", req.Method), slog.String("path", req.URL.Path),
+				slog.String("remote", req.RemoteAddr))
+			rw.Header().Set("Retry-After", "1")
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			return // the client went away while queued
+		}
+		defer func() { <-wp.workers }()
+		next.ServeHTTP(rw, req)
+	})
+}
+
+// ipFilter rejects requests from blocked client addresses with 403.
+type ipFilter struct {
+	// allow contains the allowed prefixes or is empty to allow any client.
+	allow []netip.Prefix
+
+	// deny contains the denied prefixes, which take precedence over allow.
+	deny []netip.Prefix
+}
+
+// newIPFilter returns a [*ipFilter] parsing the given CIDRs.
+func newIPFilter(allow, deny []string) (*ipFilter, error) {
+	filter := &ipFilter{}
+	for _, cidr := range allow {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, err
+		}
+		filter.allow = append(filter.allow, prefix.Masked())
+	}
+	for _, cidr := range deny {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, err
+		}
+		filter.deny = append(filter.deny, prefix.Masked())
+	}
+	return filter, nil
+}
+
+// allowed returns whether the client at remote may use the server.
+func (f *ipFilter) allowed(remote string) bool {
+	addrPort, err := netip.ParseAddrPort(remote)
+	if err != nil {
+		return false
+	}
+	addr := addrPort.Addr().Unmap()
+	for _, prefix := range f.deny {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	if len(f.allow) <= 0 {
+		return true
+	}
+	for _, prefix := range f.allow {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// wrap returns a handler that checks the client address before calling next.
+func (f *ipFilter) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if !f.allowed(req.RemoteAddr) {
+			slog.Warn("request rejected", slog.String("reason", "ip-filter"),
+				slog.String("method", req.Method), slog.String("path", req.URL.Path),
+				slog.String("remote", req.RemoteAddr))
+			rw.WriteHeader(http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(rw, req)
+	})
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bassosimone/2026-02-js-perf/internal/transfer"
+)
+
+// errInvalidALPNQuery indicates a malformed --alpn-query value.
+var errInvalidALPNQuery = errors.New("invalid ALPN query")
+
+// alpnRouter adds default query parameters depending on the negotiated ALPN.
+type alpnRouter struct {
+	// queries maps the ALPN protocol to the parameters to add.
+	queries map[string]url.Values
+}
+
+// newALPNRouter returns a [*alpnRouter] given `PROTO=QUERY` specs
+// (e.g., `h2=chunked=1&trailers=1`).
+func newALPNRouter(specs []string) (*alpnRouter, error) {
+	ar := &alpnRouter{queries: map[string]url.Values{}}
+	for _, spec := range specs {
+		proto, query, found := strings.Cut(spec, "=")
+		if !found || proto == "" {
+			return nil, fmt.Errorf("%w: %s", errInvalidALPNQuery, spec)
+		}
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", errInvalidALPNQuery, spec)
+		}
+		if ar.queries[proto] == nil {
+			ar.queries[proto] = url.Values{}
+		}
+		for key, value := range values {
+			ar.queries[proto][key] = append(ar.queries[proto][key], value...)
+		}
+	}
+	return ar, nil
+}
+
+// wrap returns a handler adding the parameters for the negotiated protocol.
+func (ar *alpnRouter) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		defaults := ar.queries[transfer.TLSALPN(req)]
+		if len(defaults) <= 0 {
+			next.ServeHTTP(rw, req)
+			return
+		}
+		query := req.URL.Query()
+		for key, value := range defaults {
+			if !query.Has(key) {
+				query[key] = value
+			}
+		}
+
+		// Like [http.StripPrefix], avoid mutating the original request.
+		r2 := new(http.Request)
+		*r2 = *req
+		r2.URL = new(url.URL)
+		*r2.URL = *req.URL
+		r2.URL.RawQuery = query.Encode()
+		next.ServeHTTP(rw, r2)
+	})
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// connStats contains the statistics of an accepted connection.
+type connStats struct {
+	active   atomic.Int64
+	requests atomic.Int64
+	start    time.Time
+}
+
+// connStatsKey is the context key for the [*connStats].
+type connStatsKey struct{}
+
+// connTracker counts the requests served by each connection.
+//
+// The zero value is ready to use.
+type connTracker struct {
+	conns sync.Map // net.Conn => *connStats
+}
+
+// open starts tracking conn and returns a context for its requests.
+func (ct *connTracker) open(ctx context.Context, conn net.Conn) context.Context {
+	stats := &connStats{start: time.Now()}
+	ct.conns.Store(conn, stats)
+	return context.WithValue(ctx, connStatsKey{}, stats)
+}
+
+// close stops tracking conn and returns the number of requests it
+// served and its lifetime.
+func (ct *connTracker) close(conn net.Conn) (int64, time.Duration) {
+	value, found := ct.conns.LoadAndDelete(conn)
+	if !found {
+		return 0, 0
+	}
+	stats := value.(*connStats)
+	return stats.requests.Load(), time.Since(stats.start)
+}
+
+// wrap returns a handler counting the request before calling next.
+func (ct *connTracker) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		stats, ok := req.Context().Value(connStatsKey{}).(*connStats)
+		if !ok {
+			next.ServeHTTP(rw, req)
+			return
+		}
+		seq := stats.requests.Add(1)
+		if req.ProtoMajor != 2 {
+			next.ServeHTTP(rw, req)
+			return
+		}
+		concurrent := stats.active.Add(1)
+		t0 := time.Now()
+		next.ServeHTTP(rw, req)
+		stats.active.Add(-1)
+
+		// net/http does not expose the stream ID, so we log the ordinal
+		// of the request within the connection instead.
+		slog.Debug("h2 stream",
+			slog.String("reqid", rw.Header().Get("X-Request-ID")),
+			slog.Int64("seq", seq),
+			slog.String("priority", req.Header.Get("Priority")),
+			slog.Int64("concurrent", concurrent),
+			slog.Duration("elapsed", time.Since(t0)),
+			slog.String("remote", req.RemoteAddr),
+		)
+	})
+}
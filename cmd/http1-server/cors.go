@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"net/http"
+	"slices"
+)
+
+// corsPolicy implements cross-origin resource sharing for the /api/{size}
+// endpoints, so that a measurement page served by another origin can use them.
+type corsPolicy struct {
+	// origins contains the allowed origins or "*" to allow any origin.
+	origins []string
+}
+
+// allowedOrigin returns the Access-Control-Allow-Origin value to emit
+// for the given request Origin, or the empty string if not allowed.
+func (cp *corsPolicy) allowedOrigin(origin string) string {
+	switch {
+	case origin == "":
+		return ""
+	case slices.Contains(cp.origins, "*"):
+		return "*"
+	case slices.Contains(cp.origins, origin):
+		return origin
+	default:
+		return ""
+	}
+}
+
+// setAllowOrigin sets the Access-Control-Allow-Origin header when
+// the request origin is allowed and returns whether it is allowed.
+func (cp *corsPolicy) setAllowOrigin(rw http.ResponseWriter, req *http.Request) bool {
+	rw.Header().Add("Vary", "Origin")
+	allowed := cp.allowedOrigin(req.Header.Get("Origin"))
+	if allowed == "" {
+		return false
+	}
+	rw.Header().Set("Access-Control-Allow-Origin", allowed)
+	return true
+}
+
+// wrap returns a handler that echoes the allowed origin before calling next.
+func (cp *corsPolicy) wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if cp.setAllowOrigin(rw, req) {
+			rw.Header().Set("Access-Control-Expose-Headers", "Server-Timing, Repr-Digest, Digest, X-Request-ID, Upload-Offset")
+		}
+		next(rw, req)
+	}
+}
+
+// handlePreflight responds to an OPTIONS preflight request.
+func (cp *corsPolicy) handlePreflight(rw http.ResponseWriter, req *http.Request) {
+	if cp.setAllowOrigin(rw, req) {
+		rw.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, PUT, POST")
+		if headers := req.Header.Get("Access-Control-Request-Headers"); headers != "" {
+			rw.Header().Set("Access-Control-Allow-Headers", headers)
+		}
+		rw.Header().Set("Access-Control-Max-Age", "600")
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
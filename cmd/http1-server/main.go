@@ -5,16 +5,35 @@ package main
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
-	"io"
+	"io/fs"
+	"log"
 	"log/slog"
+	"math"
+	"mime"
 	"net"
 	"net/http"
+	"net/netip"
 	"os"
-	"strconv"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/bassosimone/2026-02-js-perf/internal/infinite"
+	"github.com/bassosimone/2026-02-js-perf/internal/buildinfo"
+	"github.com/bassosimone/2026-02-js-perf/internal/certreload"
+	"github.com/bassosimone/2026-02-js-perf/internal/cpuaffinity"
+	"github.com/bassosimone/2026-02-js-perf/internal/envflag"
+	"github.com/bassosimone/2026-02-js-perf/internal/geoip"
+	"github.com/bassosimone/2026-02-js-perf/internal/humanize"
+	"github.com/bassosimone/2026-02-js-perf/internal/keylog"
+	"github.com/bassosimone/2026-02-js-perf/internal/netsim"
+	"github.com/bassosimone/2026-02-js-perf/internal/slogging"
+	"github.com/bassosimone/2026-02-js-perf/internal/tracing"
+	"github.com/bassosimone/2026-02-js-perf/internal/transfer"
+	"github.com/bassosimone/2026-02-js-perf/static"
 	"github.com/bassosimone/runtimex"
 	"github.com/bassosimone/vclip"
 	"github.com/bassosimone/vflag"
@@ -26,113 +45,635 @@ func main() {
 
 func serveMain(ctx context.Context, args []string) error {
 	var (
-		addressFlag   = "127.0.0.1"
-		certFlag      = "testdata/cert.pem"
-		keyFlag       = "testdata/key.pem"
-		portFlag      = "4443"
-		staticDirFlag = "./static/http1"
+		accessLogFlag         = ""
+		addressFlag           = []string{}
+		adminTokenFlag        = ""
+		allowCIDRFlag         = []string{}
+		allowCompressionFlag  = false
+		alpnFlag              = ""
+		alpnQueryFlag         = []string{}
+		bodyReadTimeoutFlag   = time.Duration(0)
+		certFlag              = "testdata/cert.pem"
+		chunkedFlag           = false
+		cipherSuitesFlag      = ""
+		clientCAFlag          = ""
+		closeAfterFlag        = false
+		congestionFlag        = ""
+		contentTypeFlag       = transfer.DefaultContentType
+		copyBufferFlag        = "1MiB"
+		corsOriginFlag        = []string{}
+		cpuAffinityFlag       = ""
+		denyCIDRFlag          = []string{}
+		drainTimeoutFlag      = 10 * time.Second
+		egressRateFlag        = ""
+		errorRateFlag         = 0.0
+		fillModeFlag          = "zero"
+		fillSeedFlag          = uint64(0)
+		firstByteDelayFlag    = time.Duration(0)
+		flushIntervalFlag     = ""
+		geoipDBFlag           = []string{}
+		gomaxprocsFlag        = 0
+		histogramOutFlag      = ""
+		idleTimeoutFlag       = time.Duration(0)
+		keyFlag               = "testdata/key.pem"
+		keylogFlag            = ""
+		listenBacklogFlag     = 0
+		logFormatFlag         = "text"
+		logLevelFlag          = "info"
+		maxConnsFlag          = 0
+		maxDurationFlag       = 60 * time.Second
+		maxHeaderBytesFlag    = http.DefaultMaxHeaderBytes
+		maxRPSFlag            = 0.0
+		maxSizeFlag           = ""
+		metricsFlag           = false
+		ocspStapleFlag        = ""
+		otelEndpointFlag      = ""
+		plaintextFlag         = false
+		portFlag              = "4443"
+		pprofAddrFlag         = ""
+		protoFlag             = "http1"
+		queueMaxFlag          = 0
+		readHeaderTimeoutFlag = 10 * time.Second
+		readTimeoutFlag       = time.Duration(0)
+		rejectOversizeFlag    = false
+		responseDelayFlag     = time.Duration(0)
+		resultsOutFlag        = ""
+		serveFileFlag         = ""
+		sessionIdleFlag       = 5 * time.Second
+		sessionTicketsFlag    = true
+		simDelayFlag          = time.Duration(0)
+		simDupFlag            = 0.0
+		simLossFlag           = 0.0
+		soRcvbufFlag          = 0
+		soSndbufFlag          = 0
+		staticDirFlag         = "./static/http1"
+		staticEmbeddedFlag    = false
+		strictSizeFlag        = false
+		summarySizeFlag       = 1000
+		tcpNodelayFlag        = true
+		tcpQuickackFlag       = false
+		tlsMaxVersionFlag     = ""
+		tlsMinVersionFlag     = ""
+		unixSocketFlag        = ""
+		uploadTTLFlag         = 10 * time.Minute
+		uploadsFlag           = 1000
+		workersFlag           = 0
+		writeTimeoutFlag      = time.Duration(0)
 	)
 
 	fset := vflag.NewFlagSet("http1-server", vflag.ExitOnError)
-	fset.StringVar(&addressFlag, 'A', "address", "Use the given IP `ADDRESS`.")
+	fset.StringVar(&accessLogFlag, 0, "access-log", "Append a Common Log Format access log to `FILE` (or - for stdout).")
+	fset.StringSliceVar(&addressFlag, 'A', "address", "Use the given IP `ADDRESS` (repeatable or comma-separated; default: 127.0.0.1).")
+	fset.StringVar(&adminTokenFlag, 0, "admin-token", "Enable POST /admin/drain authenticated by the bearer `TOKEN`.")
+	fset.StringSliceVar(&allowCIDRFlag, 0, "allow-cidr", "Only serve clients within `CIDR` (repeatable; default: any client).")
+	fset.BoolVar(&allowCompressionFlag, 0, "allow-compression", "Compress GET responses when the client accepts br, gzip, or deflate.")
+	fset.DurationVar(&bodyReadTimeoutFlag, 0, "body-read-timeout", "Fail PUT with 408 when the client sends no body bytes for `DURATION` (default: unlimited).")
+	fset.StringVar(&alpnFlag, 0, "alpn", "Advertise the comma-separated ALPN `PROTOS` in order of preference (e.g., h2,http/1.1; default: from --proto).")
+	fset.StringSliceVar(&alpnQueryFlag, 0, "alpn-query", "Add default query parameters to requests negotiating an ALPN protocol, given as `PROTO=QUERY` (e.g., h2=chunked=1; repeatable).")
 	fset.StringVar(&certFlag, 0, "cert", "Use `FILE` as the TLS certificate.")
+	fset.BoolVar(&chunkedFlag, 0, "chunked", "Send GET responses using chunked encoding rather than Content-Length.")
+	fset.StringVar(&cipherSuitesFlag, 0, "cipher-suites", "Restrict TLS 1.2 to the comma-separated cipher `SUITES`.")
+	fset.StringVar(&clientCAFlag, 0, "client-ca", "Require client certificates signed by the CAs in `FILE`.")
+	fset.BoolVar(&closeAfterFlag, 0, "close-after", "Close the connection after each GET or PUT response (also requested by ?close=1).")
+	fset.StringVar(&congestionFlag, 0, "congestion", "Use the `ALGO` TCP congestion control (e.g., bbr, cubic; Linux only).")
+	fset.StringVar(&contentTypeFlag, 0, "content-type", "Send GET responses with the given `TYPE`, unless overridden by ?type= (default: @DEFAULT_VALUE@).")
+	fset.StringVar(&copyBufferFlag, 0, "copy-buffer", "Copy GET and PUT bodies using a `SIZE` buffer (default: @DEFAULT_VALUE@).")
+	fset.StringSliceVar(&corsOriginFlag, 0, "cors-origin", "Allow cross-origin requests from `ORIGIN` (repeatable, or `*`).")
+	fset.StringVar(&cpuAffinityFlag, 0, "cpu-affinity", "Pin the process to the CPUs in `LIST` (e.g., 0-3,6; Linux only).")
+	fset.StringSliceVar(&denyCIDRFlag, 0, "deny-cidr", "Reject clients within `CIDR` with 403, even if allowed (repeatable).")
+	fset.DurationVar(&drainTimeoutFlag, 0, "drain-timeout", "Wait up to `DURATION` for active transfers on shutdown (default: @DEFAULT_VALUE@).")
+	fset.StringVar(&egressRateFlag, 0, "egress-rate", "Limit the per-connection GET rate to `RATE` (e.g., 10Mbit or 2MB/s).")
+	fset.Float64Var(&errorRateFlag, 0, "error-rate", "Fail the given `FRACTION` of GET /api/{size} requests with 500 (e.g., 0.01).")
+	fset.StringVar(&fillModeFlag, 0, "fill-mode", "Fill GET bodies using `MODE` (zero, pattern, random, or offset).")
+	fset.Uint64Var(&fillSeedFlag, 0, "fill-seed", "Seed the random fill mode with `SEED` (default: @DEFAULT_VALUE@).")
+	fset.DurationVar(&firstByteDelayFlag, 0, "first-byte-delay", "Wait `DURATION` before sending the GET response headers.")
+	fset.StringVar(&flushIntervalFlag, 0, "flush-interval", "Flush GET responses after every `SIZE` bytes, yielding smaller HTTP/2 DATA frames (e.g., 4KiB).")
+	fset.StringSliceVar(&geoipDBFlag, 0, "geoip-db", "Annotate transfers with the client location from the MaxMind database `FILE` (repeatable).")
+	fset.IntVar(&gomaxprocsFlag, 0, "gomaxprocs", "Set GOMAXPROCS to `N` (default: the number of usable CPUs).")
 	fset.AutoHelp('h', "help", "Print this help text and exit.")
+	fset.StringVar(&histogramOutFlag, 0, "histogram-out", "On shutdown, write transfer duration and size percentiles as JSON to `FILE`.")
+	fset.DurationVar(&idleTimeoutFlag, 0, "idle-timeout", "Close keep-alive connections idle for `DURATION` (default: read timeout).")
 	fset.StringVar(&keyFlag, 0, "key", "Use `FILE` as the TLS private key.")
+	fset.StringVar(&keylogFlag, 0, "keylog", "Append TLS secrets to `FILE` for decrypting packet captures (use \"env\" for $SSLKEYLOGFILE).")
+	fset.IntVar(&listenBacklogFlag, 0, "listen-backlog", "Queue up to `N` pending connections per listener (default: system maximum; Linux only).")
+	fset.StringVar(&logFormatFlag, 0, "log-format", "Log using `FORMAT` (text or json).")
+	fset.StringVar(&logLevelFlag, 0, "log-level", "Log messages at `LEVEL` or above (debug, info, warn, or error).")
+	fset.IntVar(&maxConnsFlag, 0, "max-conns", "Serve at most `N` concurrent connections per address (default: unlimited).")
+	fset.DurationVar(&maxDurationFlag, 0, "max-duration", "Reject duration-bounded GET requests longer than `DURATION` (default: @DEFAULT_VALUE@).")
+	fset.IntVar(&maxHeaderBytesFlag, 0, "max-header-bytes", "Reply 431 to requests whose headers exceed about `BYTES` (default: @DEFAULT_VALUE@).")
+	fset.Float64Var(&maxRPSFlag, 0, "max-rps", "Reject requests above `RATE` per second with 503 (default: unlimited).")
+	fset.StringVar(&maxSizeFlag, 0, "max-size", "Reject GET and PUT requests larger than `SIZE` (e.g., 10GB; default: unlimited).")
+	fset.BoolVar(&metricsFlag, 0, "metrics", "Export Prometheus metrics at /metrics.")
+	fset.StringVar(&ocspStapleFlag, 0, "ocsp-staple", "Staple the DER-encoded OCSP response in `FILE` to TLS handshakes (reloaded on SIGHUP).")
+	fset.StringVar(&otelEndpointFlag, 0, "otel-endpoint", "Export OpenTelemetry spans to the OTLP/HTTP collector at `URL` (e.g., http://localhost:4318).")
+	fset.BoolVar(&plaintextFlag, 0, "plaintext", "Serve plaintext HTTP rather than TLS (e.g., with --unix-socket).")
 	fset.StringVar(&portFlag, 'p', "port", "Use the given TCP `PORT`.")
+	fset.StringVar(&pprofAddrFlag, 0, "pprof-addr", "Serve net/http/pprof using plaintext HTTP at the loopback `ADDRESS` (e.g., 127.0.0.1:6060).")
+	fset.StringVar(&protoFlag, 0, "proto", "Serve using `PROTO` (http1, h2, or both).")
+	fset.IntVar(&queueMaxFlag, 0, "queue-max", "With --workers, reject requests with 503 when `N` are already queued (default: unlimited).")
+	fset.DurationVar(&readHeaderTimeoutFlag, 0, "read-header-timeout", "Limit reading request headers to `DURATION` (default: @DEFAULT_VALUE@).")
+	fset.DurationVar(&readTimeoutFlag, 0, "read-timeout", "Limit reading whole requests, including PUT bodies, to `DURATION`.")
+	fset.BoolVar(&rejectOversizeFlag, 0, "reject-oversize-early", "Reject PUT bodies larger than the requested size before reading them.")
+	fset.DurationVar(&responseDelayFlag, 0, "response-delay", "Wait `DURATION` before serving GET and PUT requests.")
+	fset.StringVar(&resultsOutFlag, 0, "results-out", "Append a JSON line per completed transfer to `FILE` (or - for stdout).")
+	fset.StringVar(&serveFileFlag, 0, "serve-file", "Send the first bytes of `FILE` in GET responses rather than --fill-mode bytes.")
+	fset.DurationVar(&sessionIdleFlag, 0, "session-idle", "End X-Session-ID sessions idle for `DURATION`; zero disables (default: @DEFAULT_VALUE@).")
+	fset.BoolVar(&sessionTicketsFlag, 0, "session-tickets", "Allow TLS session resumption; use =false to force full handshakes (default: @DEFAULT_VALUE@).")
+	fset.DurationVar(&simDelayFlag, 0, "sim-delay", "Delay each write to accepted connections by `DURATION`.")
+	fset.Float64Var(&simDupFlag, 0, "sim-dup", "Duplicate writes with probability `P` (coarse; corrupts the stream).")
+	fset.Float64Var(&simLossFlag, 0, "sim-loss", "Drop writes with probability `P` (coarse; corrupts the stream).")
+	fset.IntVar(&soRcvbufFlag, 0, "so-rcvbuf", "Set SO_RCVBUF to `BYTES` on accepted connections.")
+	fset.IntVar(&soSndbufFlag, 0, "so-sndbuf", "Set SO_SNDBUF to `BYTES` on accepted connections.")
 	fset.StringVar(&staticDirFlag, 0, "static-dir", "Serve static files from `DIR`.")
-	runtimex.PanicOnError0(fset.Parse(args))
+	fset.BoolVar(&staticEmbeddedFlag, 0, "static-embedded", "Serve the static files embedded in the binary rather than --static-dir.")
+	fset.BoolVar(&strictSizeFlag, 0, "strict-size", "Fail PUT with 400 when the body size differs from the requested size or Content-Length.")
+	fset.IntVar(&summarySizeFlag, 0, "summary-size", "Keep the last `N` transfers at /summary; zero disables (default: @DEFAULT_VALUE@).")
+	fset.BoolVar(&tcpNodelayFlag, 0, "tcp-nodelay", "Disable Nagle's algorithm; use =false to enable it (default: @DEFAULT_VALUE@).")
+	fset.BoolVar(&tcpQuickackFlag, 0, "tcp-quickack", "Enable TCP_QUICKACK on accepted connections (Linux only).")
+	fset.StringVar(&tlsMaxVersionFlag, 0, "tls-max-version", "Use at most TLS `VERSION` (1.2 or 1.3).")
+	fset.StringVar(&tlsMinVersionFlag, 0, "tls-min-version", "Use at least TLS `VERSION` (1.2 or 1.3).")
+	fset.StringVar(&unixSocketFlag, 0, "unix-socket", "Listen on the Unix domain socket at `PATH` rather than TCP.")
+	fset.DurationVar(&uploadTTLFlag, 0, "upload-ttl", "Forget resumable uploads idle for `DURATION` (default: @DEFAULT_VALUE@).")
+	fset.IntVar(&uploadsFlag, 0, "uploads", "Track up to `N` resumable uploads at a time; zero disables (default: @DEFAULT_VALUE@).")
+	fset.IntVar(&workersFlag, 0, "workers", "Serve at most `N` concurrent requests, queueing the others (default: unlimited).")
+	fset.DurationVar(&writeTimeoutFlag, 0, "write-timeout", "Limit writing responses, including GET bodies, to `DURATION` (default: unlimited).")
+	runtimex.LogFatalOnError0(envflag.Parse(fset, args))
+	runtimex.LogFatalOnError0(slogging.Setup(logFormatFlag, logLevelFlag))
+	runtimex.LogFatalOnError0(cpuaffinity.Setup(gomaxprocsFlag, cpuAffinityFlag))
+
+	var addresses []string
+	for _, value := range addressFlag {
+		addresses = append(addresses, strings.Split(value, ",")...)
+	}
+	if len(addresses) <= 0 {
+		addresses = []string{"127.0.0.1"}
+	}
+	endpoints := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		endpoints = append(endpoints, net.JoinHostPort(address, portFlag))
+	}
+	if unixSocketFlag != "" {
+		endpoints = []string{unixSocketFlag}
+	}
+
+	copyBufferSize := runtimex.LogFatalOnError1(transfer.ParseSize(copyBufferFlag))
+	if copyBufferSize <= 0 || copyBufferSize > math.MaxInt32 {
+		log.Fatalf("http1-server: invalid copy buffer size: %s", copyBufferFlag)
+	}
+	slog.Info("copy buffer", slog.String("size", humanize.IEC(float64(copyBufferSize), "B")))
+
+	if pprofAddrFlag != "" && !isLoopbackAddr(pprofAddrFlag) {
+		log.Fatalf("http1-server: invalid pprof address (must be loopback): %s", pprofAddrFlag)
+	}
+
+	netsimConfig := &netsim.Config{
+		Delay:     simDelayFlag,
+		Duplicate: simDupFlag,
+		Loss:      simLossFlag,
+	}
+	runtimex.LogFatalOnError0(netsimConfig.Validate())
+	if netsimConfig.Enabled() {
+		slog.Warn("simulating impaired links", slog.Duration("delay", simDelayFlag),
+			slog.Float64("dup", simDupFlag), slog.Float64("loss", simLossFlag))
+	}
+
+	var maxSize int64
+	if maxSizeFlag != "" {
+		maxSize = runtimex.LogFatalOnError1(transfer.ParseSize(maxSizeFlag))
+	}
+
+	var flushInterval int64
+	if flushIntervalFlag != "" {
+		flushInterval = runtimex.LogFatalOnError1(transfer.ParseSize(flushIntervalFlag))
+		if flushInterval <= 0 {
+			log.Fatalf("http1-server: invalid flush interval: %s", flushIntervalFlag)
+		}
+		slog.Info("flush interval", slog.String("size", humanize.IEC(float64(flushInterval), "B")))
+	}
+
+	var egressRate float64
+	if egressRateFlag != "" {
+		egressRate = runtimex.LogFatalOnError1(transfer.ParseRate(egressRateFlag))
+		slog.Info("egress rate", slog.String("rate", humanize.SI(egressRate*8, "bit/s")))
+	}
+
+	if !(errorRateFlag >= 0 && errorRateFlag <= 1) {
+		log.Fatalf("http1-server: invalid error rate: %g", errorRateFlag)
+	}
+	if maxHeaderBytesFlag <= 0 {
+		log.Fatalf("http1-server: invalid max header bytes: %d", maxHeaderBytesFlag)
+	}
+	if workersFlag < 0 || queueMaxFlag < 0 {
+		log.Fatalf("http1-server: invalid worker pool: --workers %d --queue-max %d", workersFlag, queueMaxFlag)
+	}
+	fillReader := runtimex.LogFatalOnError1(transfer.NewFillFunc(fillModeFlag, fillSeedFlag))
+	if allowCompressionFlag && fillModeFlag != "random" {
+		slog.Warn("compressing compressible fill data; use --fill-mode random to measure compression",
+			slog.String("fillMode", fillModeFlag))
+	}
+	runtimex.LogFatalOnError2(mime.ParseMediaType(contentTypeFlag))
+
+	// Clients without a valid certificate fail the TLS handshake
+	// and never reach the HTTP handlers.
+	var clientCAs *x509.CertPool
+	if clientCAFlag != "" {
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(runtimex.LogFatalOnError1(os.ReadFile(clientCAFlag))) {
+			log.Fatalf("http1-server: no certificates in %s", clientCAFlag)
+		}
+	}
+
+	h := &transfer.Handler{
+		AllowCompression:    allowCompressionFlag,
+		BodyReadTimeout:     bodyReadTimeoutFlag,
+		Chunked:             chunkedFlag,
+		CloseAfter:          closeAfterFlag,
+		ContentType:         contentTypeFlag,
+		CopyBufferSize:      int(copyBufferSize),
+		ErrorRate:           errorRateFlag,
+		FillReader:          fillReader,
+		FirstByteDelay:      firstByteDelayFlag,
+		FlushInterval:       flushInterval,
+		MaxDuration:         maxDurationFlag,
+		MaxSize:             maxSize,
+		RejectOversizeEarly: rejectOversizeFlag,
+		ResponseDelay:       responseDelayFlag,
+		ServeFile:           serveFileFlag,
+		StrictSize:          strictSizeFlag,
+	}
+	if histogramOutFlag != "" {
+		h.Histograms = transfer.NewHistograms()
+	}
+	if resultsOutFlag != "" {
+		h.Results = runtimex.LogFatalOnError1(transfer.OpenResults(resultsOutFlag))
+	}
+
+	// Serving a file only uses the sendfile fast path with plaintext
+	// HTTP/1.1, since Go implements TLS and HTTP/2 framing in userspace.
+	if serveFileFlag != "" {
+		info := runtimex.LogFatalOnError1(os.Stat(serveFileFlag))
+		slog.Info("serving file", slog.String("file", serveFileFlag), slog.Int64("size", info.Size()))
+	}
+	if metricsFlag {
+		h.Metrics = &transfer.Metrics{}
+	}
+	if summarySizeFlag > 0 {
+		h.Summary = transfer.NewSummary(summarySizeFlag)
+	}
+	if sessionIdleFlag > 0 {
+		h.Sessions = transfer.NewSessions(sessionIdleFlag, h.Summary)
+	}
+	if uploadsFlag > 0 {
+		h.Uploads = transfer.NewUploads(uploadsFlag, uploadTTLFlag)
+	}
+
+	var getHandler, getDurationHandler, putHandler http.HandlerFunc = h.HandleGet, h.HandleGetDuration, h.HandlePut
+	var pingHandler, rttHandler, statusHandler http.HandlerFunc = h.HandlePing, h.HandleRTT, h.HandleStatus
+	caps := h.Capabilities()
+	var capsHandler, echoHandler http.HandlerFunc = caps.HandleCapabilities, h.HandleEcho
+	var uploadHeadHandler, uploadPutHandler http.HandlerFunc = h.HandleUploadHead, h.HandleUploadPut
 
 	mux := http.NewServeMux()
-	mux.Handle("GET /api/{size}", http.HandlerFunc(handleGet))
-	mux.Handle("PUT /api/{size}", http.HandlerFunc(handlePut))
-	mux.Handle("/", http.FileServer(http.Dir(staticDirFlag)))
-
-	endpoint := net.JoinHostPort(addressFlag, portFlag)
-	srv := &http.Server{
-		Addr:    endpoint,
-		Handler: mux,
-		TLSConfig: &tls.Config{
-			NextProtos: []string{"http/1.1"},
+	if len(corsOriginFlag) > 0 {
+		cors := &corsPolicy{origins: corsOriginFlag}
+		getHandler, putHandler = cors.wrap(getHandler), cors.wrap(putHandler)
+		getDurationHandler, rttHandler = cors.wrap(getDurationHandler), cors.wrap(rttHandler)
+		pingHandler, statusHandler = cors.wrap(pingHandler), cors.wrap(statusHandler)
+		capsHandler, echoHandler = cors.wrap(capsHandler), cors.wrap(echoHandler)
+		uploadHeadHandler, uploadPutHandler = cors.wrap(uploadHeadHandler), cors.wrap(uploadPutHandler)
+		mux.Handle("OPTIONS /api", http.HandlerFunc(cors.handlePreflight))
+		mux.Handle("OPTIONS /api/{size}", http.HandlerFunc(cors.handlePreflight))
+		mux.Handle("OPTIONS /api/duration/{seconds}", http.HandlerFunc(cors.handlePreflight))
+		mux.Handle("OPTIONS /api/echo", http.HandlerFunc(cors.handlePreflight))
+		mux.Handle("OPTIONS /api/upload/{id}", http.HandlerFunc(cors.handlePreflight))
+	}
+	mux.Handle("GET /api", getHandler)
+	mux.Handle("GET /api/{size}", getHandler)
+	mux.Handle("GET /api/capabilities", capsHandler)
+	mux.Handle("GET /api/duration/{seconds}", getDurationHandler)
+	mux.Handle("GET /api/ping", pingHandler)
+	mux.Handle("GET /api/rtt", rttHandler)
+	mux.Handle("GET /api/status/{code}", statusHandler)
+	mux.Handle("PUT /api", putHandler)
+	mux.Handle("PUT /api/{size}", putHandler)
+	mux.Handle("POST /api", putHandler)
+	mux.Handle("POST /api/{size}", putHandler)
+	mux.Handle("POST /api/echo", echoHandler)
+	if h.Uploads != nil {
+		mux.Handle("HEAD /api/upload/{id}", uploadHeadHandler)
+		mux.Handle("PUT /api/upload/{id}", uploadPutHandler)
+	}
+	if h.Metrics != nil {
+		mux.Handle("GET /metrics", http.HandlerFunc(h.Metrics.HandleMetrics))
+	}
+	if h.Summary != nil {
+		mux.Handle("GET /summary", http.HandlerFunc(h.Summary.HandleSummary))
+	}
+	mux.Handle("GET /version", http.HandlerFunc(buildinfo.HandleVersion))
+
+	// Draining on request cancels the same context that SIGINT would.
+	ctx, drain := context.WithCancel(ctx)
+	defer drain()
+	if adminTokenFlag != "" {
+		admin := &adminDrain{drain: drain, token: adminTokenFlag}
+		mux.Handle("POST /admin/drain", http.HandlerFunc(admin.handleDrain))
+	}
+	if staticEmbeddedFlag {
+		mux.Handle("/", http.FileServerFS(runtimex.PanicOnError1(fs.Sub(static.FS, "http1"))))
+	} else {
+		mux.Handle("/", http.FileServer(http.Dir(staticDirFlag)))
+	}
+
+	var geoDB *geoip.DB
+	if len(geoipDBFlag) > 0 {
+		geoDB = runtimex.LogFatalOnError1(geoip.Open(geoipDBFlag...))
+		defer geoDB.Close()
+	}
+	var handler http.Handler = geoDB.Wrap(mux)
+	if len(alpnQueryFlag) > 0 {
+		handler = runtimex.LogFatalOnError1(newALPNRouter(alpnQueryFlag)).wrap(handler)
+	}
+	if len(allowCIDRFlag) > 0 || len(denyCIDRFlag) > 0 {
+		handler = runtimex.LogFatalOnError1(newIPFilter(allowCIDRFlag, denyCIDRFlag)).wrap(handler)
+	}
+	if workersFlag > 0 {
+		handler = newWorkerPool(workersFlag, queueMaxFlag).wrap(handler)
+	}
+	if maxRPSFlag > 0 {
+		handler = newRPSLimiter(maxRPSFlag).wrap(handler)
+	}
+	if accessLogFlag != "" {
+		handler = runtimex.LogFatalOnError1(openAccessLog(accessLogFlag)).wrap(handler)
+	}
+	if otelEndpointFlag != "" {
+		shutdown := runtimex.LogFatalOnError1(tracing.Setup(ctx, otelEndpointFlag, "http1-server"))
+		defer shutdown(context.Background())
+		handler = tracing.Wrap(handler, "http1-server")
+	}
+
+	sockopts := &socketOptions{
+		backlog:    listenBacklogFlag,
+		congestion: congestionFlag,
+		nodelay:    tcpNodelayFlag,
+		quickack:   tcpQuickackFlag,
+		rcvbuf:     soRcvbufFlag,
+		sndbuf:     soSndbufFlag,
+	}
+
+	protocols := &http.Protocols{}
+	var nextProtos []string
+	switch protoFlag {
+	case "http1":
+		protocols.SetHTTP1(true)
+		nextProtos = []string{"http/1.1"}
+	case "h2":
+		protocols.SetHTTP2(true)
+		nextProtos = []string{"h2"}
+	case "both":
+		protocols.SetHTTP1(true)
+		protocols.SetHTTP2(true)
+		nextProtos = []string{"h2", "http/1.1"}
+	default:
+		log.Fatalf("http1-server: invalid protocol: %s", protoFlag)
+	}
+	if alpn := runtimex.LogFatalOnError1(parseALPN(alpnFlag, protocols)); alpn != nil {
+		protocols.SetHTTP1(slices.Contains(alpn, "http/1.1"))
+		protocols.SetHTTP2(slices.Contains(alpn, "h2"))
+		nextProtos = alpn
+	}
+
+	tlsConfig := &tls.Config{
+		CipherSuites: runtimex.LogFatalOnError1(parseCipherSuites(cipherSuitesFlag)),
+		MaxVersion:   runtimex.LogFatalOnError1(parseTLSVersion(tlsMaxVersionFlag)),
+		MinVersion:   runtimex.LogFatalOnError1(parseTLSVersion(tlsMinVersionFlag)),
+		NextProtos:   nextProtos,
+
+		SessionTicketsDisabled: !sessionTicketsFlag,
+	}
+	if clientCAs != nil {
+		tlsConfig.ClientCAs = clientCAs
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	// Without TLS there is no ALPN, so HTTP/2 requires prior knowledge.
+	if plaintextFlag {
+		protocols.SetUnencryptedHTTP2(protocols.HTTP2())
+		protocols.SetHTTP2(false)
+		tlsConfig = nil
+	} else {
+		certs := runtimex.LogFatalOnError1(certreload.New(certFlag, keyFlag, ocspStapleFlag))
+		go certs.WatchSIGHUP(ctx)
+		tlsConfig.GetCertificate = certs.GetCertificate
+		caps.SetTLSVersions(tlsConfig)
+		if keylogPath := keylog.Path(keylogFlag); keylogPath != "" {
+			keylogFile := runtimex.LogFatalOnError1(keylog.Open(keylogPath))
+			defer keylogFile.Close()
+			tlsConfig.KeyLogWriter = keylogFile
+		}
+	}
+	if protocols.HTTP1() {
+		caps.Protocols = append(caps.Protocols, "http/1.1")
+	}
+	if protocols.HTTP2() {
+		caps.Protocols = append(caps.Protocols, "h2")
+	}
+	if protocols.UnencryptedHTTP2() {
+		caps.Protocols = append(caps.Protocols, "h2c")
+	}
+
+	var (
+		activeConns atomic.Int64
+		tracker     connTracker
+	)
+	handler = tracker.wrap(handler)
+
+	sc := &serverConfig{
+		connContext: func(ctx context.Context, conn net.Conn) context.Context {
+			ctx = tracker.open(ctx, conn)
+			ctx = transfer.WithConn(ctx, conn)
+			if egressRate > 0 {
+				ctx = transfer.WithLimiter(ctx, transfer.NewLimiter(egressRate))
+			}
+			return ctx
 		},
-		ConnState: func(conn net.Conn, state http.ConnState) {
+		connState: func(conn net.Conn, state http.ConnState) {
 			switch state {
 			case http.StateNew:
+				activeConns.Add(1)
+				h.Metrics.ConnOpened()
 				slog.Info("conn new", slog.String("remote", conn.RemoteAddr().String()))
-			case http.StateClosed:
-				slog.Info("conn closed", slog.String("remote", conn.RemoteAddr().String()))
+				if unixSocketFlag == "" {
+					sockopts.applyConn(conn)
+					sockopts.logConn(conn)
+				}
+			case http.StateClosed, http.StateHijacked:
+				activeConns.Add(-1)
+				requests, lifetime := tracker.close(conn)
+				h.Metrics.ConnClosed(requests)
+				slog.Info("conn closed", slog.String("remote", conn.RemoteAddr().String()),
+					slog.Int64("requests", requests), slog.Duration("lifetime", lifetime))
 			}
 		},
+		handler:           handler,
+		idleTimeout:       idleTimeoutFlag,
+		maxHeaderBytes:    maxHeaderBytesFlag,
+		protocols:         protocols,
+		readHeaderTimeout: readHeaderTimeoutFlag,
+		readTimeout:       readTimeoutFlag,
+		tlsConfig:         tlsConfig,
+		writeTimeout:      writeTimeoutFlag,
 	}
+	servers := make([]*http.Server, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		servers = append(servers, sc.newServer(endpoint))
+	}
+
+	if h.Metrics != nil {
+		go h.Metrics.Sample(ctx, time.Second)
+	}
+	if pprofAddrFlag != "" {
+		startPprof(ctx, pprofAddrFlag)
+	}
+
+	drained := make(chan struct{})
 	go func() {
-		defer srv.Close()
+		defer close(drained)
 		<-ctx.Done()
+		slog.Info("draining", slog.Int64("activeConns", activeConns.Load()),
+			slog.Duration("timeout", drainTimeoutFlag))
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), drainTimeoutFlag)
+		defer cancel()
+		wg := &sync.WaitGroup{}
+		for _, srv := range servers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := srv.Shutdown(shutdownCtx); err != nil {
+					slog.Warn("drain incomplete; closing", slog.String("addr", srv.Addr),
+						slog.Any("err", err), slog.Int64("activeConns", activeConns.Load()))
+					srv.Close()
+				}
+			}()
+		}
+		wg.Wait()
 	}()
 
-	slog.Info("serving at", slog.String("addr", endpoint))
-	err := srv.ListenAndServeTLS(certFlag, keyFlag)
-	slog.Info("interrupted", slog.Any("err", err))
+	// With multiple addresses we bind each IP family separately, such
+	// that, e.g., `-A 0.0.0.0,::` does not fail with EADDRINUSE because
+	// the IPv6 wildcard listener would otherwise also accept IPv4.
+	lc := &net.ListenConfig{Control: sockopts.control}
+	listeners := make([]net.Listener, 0, len(servers))
+	for _, srv := range servers {
+		var ln net.Listener
+		if unixSocketFlag != "" {
+			ln = runtimex.LogFatalOnError1(listenUnix(ctx, unixSocketFlag))
+		} else {
+			network := "tcp"
+			if len(servers) > 1 {
+				network = listenNetwork(srv.Addr)
+			}
+			ln = runtimex.LogFatalOnError1(lc.Listen(ctx, network, srv.Addr))
+			go sockopts.watchAcceptQueue(ctx, ln, time.Second)
+		}
+		runtimex.LogFatalOnError0(sockopts.applyListener(ln))
+		if netsimConfig.Enabled() {
+			ln = netsim.NewListener(ln, netsimConfig)
+		}
+		if maxConnsFlag > 0 {
+			ln = newLimitListener(ln, maxConnsFlag)
+		}
+		listeners = append(listeners, ln)
+	}
+
+	errch := make(chan error, len(servers))
+	for idx, srv := range servers {
+		slog.Info("serving at", slog.String("addr", srv.Addr))
+		go func() {
+			if plaintextFlag {
+				errch <- srv.Serve(listeners[idx])
+				return
+			}
+			errch <- srv.ServeTLS(listeners[idx], "", "")
+		}()
+	}
+	for range servers {
+		err := <-errch
+		slog.Info("interrupted", slog.Any("err", err))
+		if !errors.Is(err, http.ErrServerClosed) {
+			runtimex.LogFatalOnError0(err)
+		}
+	}
+	<-drained
 
-	if errors.Is(err, http.ErrServerClosed) {
-		err = nil
+	if histogramOutFlag != "" {
+		runtimex.LogFatalOnError0(h.Histograms.WriteFile(histogramOutFlag))
+		slog.Info("histograms written", slog.String("file", histogramOutFlag))
 	}
-	runtimex.LogFatalOnError0(err)
 	return nil
 }
 
-func tlsALPN(req *http.Request) string {
-	if req.TLS != nil {
-		return req.TLS.NegotiatedProtocol
+// serverConfig contains the settings shared by the server at each endpoint.
+type serverConfig struct {
+	connContext       func(ctx context.Context, conn net.Conn) context.Context
+	connState         func(conn net.Conn, state http.ConnState)
+	handler           http.Handler
+	idleTimeout       time.Duration
+	maxHeaderBytes    int
+	protocols         *http.Protocols
+	readHeaderTimeout time.Duration
+	readTimeout       time.Duration
+	tlsConfig         *tls.Config
+	writeTimeout      time.Duration
+}
+
+// newServer returns the [*http.Server] serving at endpoint.
+func (sc *serverConfig) newServer(endpoint string) *http.Server {
+	return &http.Server{
+		Addr:      endpoint,
+		Handler:   sc.handler,
+		Protocols: sc.protocols,
+		TLSConfig: sc.tlsConfig,
+
+		// The read and write timeouts bound the whole PUT and GET
+		// transfers, so keep them disabled unless debugging stuck
+		// clients; the header timeout alone sheds slowloris clients.
+		IdleTimeout:       sc.idleTimeout,
+		ReadHeaderTimeout: sc.readHeaderTimeout,
+		ReadTimeout:       sc.readTimeout,
+		WriteTimeout:      sc.writeTimeout,
+
+		// Go allows a few extra KiB of slack beyond this limit.
+		MaxHeaderBytes: sc.maxHeaderBytes,
+
+		ConnContext: sc.connContext,
+		ConnState:   sc.connState,
 	}
-	return ""
 }
 
-func handleGet(rw http.ResponseWriter, req *http.Request) {
-	count, err := strconv.ParseInt(req.PathValue("size"), 10, 64)
-	if err != nil || count < 0 {
-		rw.WriteHeader(http.StatusBadRequest)
-		return
-	}
-	slog.Info("GET",
-		slog.Int64("count", count),
-		slog.String("proto", req.Proto),
-		slog.String("alpn", tlsALPN(req)),
-		slog.String("remote", req.RemoteAddr),
-	)
-	t0 := time.Now()
-	bodyReader := io.LimitReader(infinite.Reader{}, count)
-	rw.Header().Set("Content-Length", strconv.FormatInt(count, 10))
-	rw.WriteHeader(http.StatusOK)
-	buf := make([]byte, 1<<20) // 1 MiB
-	written, _ := io.CopyBuffer(rw, bodyReader, buf)
-	elapsed := time.Since(t0)
-	slog.Info("GET done",
-		slog.Int64("bytes", written),
-		slog.Duration("elapsed", elapsed),
-		slog.String("remote", req.RemoteAddr),
-	)
+// listenUnix listens on the Unix domain socket at path, replacing a stale one.
+func listenUnix(ctx context.Context, path string) (net.Listener, error) {
+	if info, err := os.Stat(path); err == nil && info.Mode().Type() == fs.ModeSocket {
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	return (&net.ListenConfig{}).Listen(ctx, "unix", path)
 }
 
-func handlePut(rw http.ResponseWriter, req *http.Request) {
-	expectCount, err := strconv.ParseInt(req.PathValue("size"), 10, 64)
-	if err != nil || expectCount < 0 {
-		rw.WriteHeader(http.StatusBadRequest)
-		return
-	}
-	slog.Info("PUT",
-		slog.Int64("expectCount", expectCount),
-		slog.String("proto", req.Proto),
-		slog.String("alpn", tlsALPN(req)),
-		slog.String("remote", req.RemoteAddr),
-	)
-	t0 := time.Now()
-	bodyReader := io.LimitReader(req.Body, expectCount)
-	buf := make([]byte, 1<<20) // 1 MiB
-	read, _ := io.CopyBuffer(io.Discard, bodyReader, buf)
-	elapsed := time.Since(t0)
-	slog.Info("PUT done",
-		slog.Int64("bytes", read),
-		slog.Duration("elapsed", elapsed),
-		slog.String("remote", req.RemoteAddr),
-	)
-	rw.WriteHeader(http.StatusNoContent)
+// listenNetwork returns the network to bind the given endpoint using only
+// the IP family of its address, or "tcp" when the address is not an IP.
+func listenNetwork(endpoint string) string {
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return "tcp"
+	}
+	addr, err := netip.ParseAddr(host)
+	switch {
+	case err != nil:
+		return "tcp"
+	case addr.Is4():
+		return "tcp4"
+	default:
+		return "tcp6"
+	}
 }
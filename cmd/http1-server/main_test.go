@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaxHeaderBytes(t *testing.T) {
+	cases := []struct {
+		maxHeaderBytes int
+		cookieSize     int
+		status         int
+	}{
+		{http.DefaultMaxHeaderBytes, 8 << 10, http.StatusOK},
+		{1024, 128, http.StatusOK},
+		{1024, 8 << 10, http.StatusRequestHeaderFieldsTooLarge},
+	}
+	for _, tc := range cases {
+		socket := filepath.Join(t.TempDir(), "http1.sock")
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- serveMain(ctx, []string{
+				"--max-header-bytes", strconv.Itoa(tc.maxHeaderBytes),
+				"--plaintext",
+				"--unix-socket", socket,
+			})
+		}()
+
+		client := &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		}}
+		req, err := http.NewRequest(http.MethodGet, "http://http1-server/api/ping", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Cookie", "c="+strings.Repeat("x", tc.cookieSize))
+
+		// The server listens asynchronously, so retry until it accepts.
+		var resp *http.Response
+		for attempt := 0; attempt < 100; attempt++ {
+			if resp, err = client.Do(req); err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		client.CloseIdleConnections()
+		cancel()
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tc.status {
+			t.Fatalf("max %d, cookie %d: got %d, want %d", tc.maxHeaderBytes, tc.cookieSize, resp.StatusCode, tc.status)
+		}
+	}
+}
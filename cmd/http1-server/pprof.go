@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"net/netip"
+
+	"github.com/bassosimone/runtimex"
+)
+
+// startPprof serves the [net/http/pprof] handlers at address until ctx is done.
+func startPprof(ctx context.Context, address string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	srv := &http.Server{Handler: mux}
+
+	ln := runtimex.LogFatalOnError1((&net.ListenConfig{}).Listen(ctx, "tcp", address))
+	slog.Info("serving pprof at", slog.String("addr", ln.Addr().String()))
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	go func() {
+		if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+			slog.Warn("pprof server failed", slog.Any("err", err))
+		}
+	}()
+}
+
+// isLoopbackAddr returns whether address is a host:port whose host is
+// localhost or a loopback IP address. An empty host is not loopback.
+func isLoopbackAddr(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && addr.IsLoopback()
+}
@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import "testing"
+
+func TestIsLoopbackAddr(t *testing.T) {
+	cases := []struct {
+		address string
+		want    bool
+	}{
+		{"127.0.0.1:6060", true},
+		{"127.1.2.3:6060", true},
+		{"[::1]:6060", true},
+		{"localhost:6060", true},
+		{":6060", false},
+		{"0.0.0.0:6060", false},
+		{"[::]:6060", false},
+		{"192.0.2.1:6060", false},
+		{"example.com:6060", false},
+		{"127.0.0.1", false},
+	}
+	for _, tc := range cases {
+		if got := isLoopbackAddr(tc.address); got != tc.want {
+			t.Errorf("%q: got %v, want %v", tc.address, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/bassosimone/2026-02-js-perf/internal/sockopt"
+)
+
+// socketOptions contains the socket options to apply to connections.
+type socketOptions struct {
+	// backlog is the accept queue size or zero to use the default.
+	backlog int
+
+	// congestion is the TCP congestion control algorithm (Linux only)
+	// or the empty string to use the system default.
+	congestion string
+
+	// nodelay controls TCP_NODELAY, i.e., disables Nagle's algorithm.
+	nodelay bool
+
+	// quickack enables TCP_QUICKACK (Linux only).
+	quickack bool
+
+	// rcvbuf is the SO_RCVBUF value or zero to use the default.
+	rcvbuf int
+
+	// sndbuf is the SO_SNDBUF value or zero to use the default.
+	sndbuf int
+}
+
+// control implements the [net.ListenConfig] Control callback.
+func (so *socketOptions) control(network, address string, conn syscall.RawConn) error {
+	if so.rcvbuf > 0 {
+		if err := sockopt.SetInt(conn, syscall.SOL_SOCKET, syscall.SO_RCVBUF, so.rcvbuf); err != nil {
+			return err
+		}
+	}
+	if so.sndbuf > 0 {
+		if err := sockopt.SetInt(conn, syscall.SOL_SOCKET, syscall.SO_SNDBUF, so.sndbuf); err != nil {
+			return err
+		}
+	}
+	if so.congestion != "" {
+		// The kernel rejects unknown or unloaded algorithms, in which case
+		// we keep using the default rather than refusing to serve.
+		if err := sockopt.SetCongestion(conn, so.congestion); err != nil {
+			slog.Warn("cannot set congestion control; using the default",
+				slog.String("congestion", so.congestion),
+				slog.Any("err", err),
+			)
+		}
+	}
+	return nil
+}
+
+// applyConn applies the options that accepted sockets do not inherit.
+func (so *socketOptions) applyConn(conn net.Conn) {
+	rawConn, err := sockopt.SyscallConn(conn)
+	if err != nil {
+		slog.Warn("cannot access socket", slog.Any("err", err))
+		return
+	}
+	nodelay := 0
+	if so.nodelay {
+		nodelay = 1
+	}
+	if err := sockopt.SetInt(rawConn, syscall.IPPROTO_TCP, syscall.TCP_NODELAY, nodelay); err != nil {
+		slog.Warn("cannot set TCP_NODELAY", slog.Any("err", err))
+	}
+	if so.quickack {
+		if err := sockopt.SetQuickAck(rawConn, true); err != nil {
+			slog.Warn("cannot set TCP_QUICKACK", slog.Any("err", err))
+		}
+	}
+}
+
+// logConn logs the requested and actual socket options of an accepted
+// connection, since the kernel may clamp or adjust the requested values.
+func (so *socketOptions) logConn(conn net.Conn) {
+	rawConn, err := sockopt.SyscallConn(conn)
+	if err != nil {
+		slog.Warn("cannot access socket", slog.Any("err", err))
+		return
+	}
+	rcvbuf, _ := sockopt.GetInt(rawConn, syscall.SOL_SOCKET, syscall.SO_RCVBUF)
+	sndbuf, _ := sockopt.GetInt(rawConn, syscall.SOL_SOCKET, syscall.SO_SNDBUF)
+	congestion, _ := sockopt.GetCongestion(rawConn)
+	nodelay, _ := sockopt.GetInt(rawConn, syscall.IPPROTO_TCP, syscall.TCP_NODELAY)
+	quickack, _ := sockopt.GetQuickAck(rawConn)
+	slog.Info("conn sockopts",
+		slog.String("congestionRequested", so.congestion),
+		slog.String("congestion", congestion),
+		slog.Bool("nodelay", nodelay != 0),
+		slog.Bool("quickack", quickack),
+		slog.Int("rcvbufRequested", so.rcvbuf),
+		slog.Int("rcvbuf", rcvbuf),
+		slog.Int("sndbufRequested", so.sndbuf),
+		slog.Int("sndbuf", sndbuf),
+		slog.String("remote", conn.RemoteAddr().String()),
+	)
+}
+
+// applyListener applies the options that we cannot set using the
+// [net.ListenConfig] Control callback, which runs before listen(2).
+func (so *socketOptions) applyListener(ln net.Listener) error {
+	if so.backlog <= 0 {
+		return nil
+	}
+	rawConn, err := listenerSyscallConn(ln)
+	if err != nil {
+		return err
+	}
+	return sockopt.SetBacklog(rawConn, so.backlog)
+}
+
+// watchAcceptQueue periodically logs the accept queue of ln until ctx is done.
+func (so *socketOptions) watchAcceptQueue(ctx context.Context, ln net.Listener, interval time.Duration) {
+	rawConn, err := listenerSyscallConn(ln)
+	if err != nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			queued, backlog, err := sockopt.AcceptQueue(rawConn)
+			if errors.Is(err, errors.ErrUnsupported) {
+				return
+			}
+			if err != nil {
+				continue
+			}
+			level := slog.LevelDebug
+			if backlog > 0 && queued >= backlog {
+				level = slog.LevelWarn
+			}
+			slog.Log(ctx, level, "accept queue", slog.String("addr", ln.Addr().String()),
+				slog.Int("queued", queued), slog.Int("backlog", backlog))
+		}
+	}
+}
+
+// listenerSyscallConn returns the [syscall.RawConn] underlying a listener.
+func listenerSyscallConn(ln net.Listener) (syscall.RawConn, error) {
+	sc, ok := ln.(syscall.Conn)
+	if !ok {
+		return nil, syscall.EINVAL
+	}
+	return sc.SyscallConn()
+}
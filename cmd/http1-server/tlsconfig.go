@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// parseTLSVersion parses a TLS version flag (1.2 or 1.3); empty means zero.
+func parseTLSVersion(value string) (uint16, error) {
+	switch value {
+	case "":
+		return 0, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("invalid TLS version: %s", value)
+	}
+}
+
+// parseCipherSuites parses a comma-separated list of cipher suite names.
+func parseCipherSuites(value string) ([]uint16, error) {
+	if value == "" {
+		return nil, nil
+	}
+	suites := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		suites[suite.Name] = suite.ID
+	}
+	var ids []uint16
+	for _, name := range strings.Split(value, ",") {
+		id, found := suites[strings.TrimSpace(name)]
+		if !found {
+			return nil, fmt.Errorf("invalid cipher suite: %s", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parseALPN parses a comma-separated, ordered list of ALPN protocols.
+func parseALPN(value string, protocols *http.Protocols) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var alpn []string
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		switch {
+		case slices.Contains(alpn, entry):
+			return nil, fmt.Errorf("duplicate ALPN protocol: %s", entry)
+		case entry == "http/1.1" && protocols.HTTP1():
+		case entry == "h2" && protocols.HTTP2():
+		default:
+			return nil, fmt.Errorf("unsupported ALPN protocol: %s", entry)
+		}
+		alpn = append(alpn, entry)
+	}
+	return alpn, nil
+}
@@ -0,0 +1,218 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"log"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/bassosimone/2026-02-js-perf/internal/certreload"
+	"github.com/bassosimone/2026-02-js-perf/internal/cpuaffinity"
+	"github.com/bassosimone/2026-02-js-perf/internal/envflag"
+	"github.com/bassosimone/2026-02-js-perf/internal/geoip"
+	"github.com/bassosimone/2026-02-js-perf/internal/keylog"
+	"github.com/bassosimone/2026-02-js-perf/internal/slogging"
+	"github.com/bassosimone/2026-02-js-perf/internal/transfer"
+	"github.com/bassosimone/runtimex"
+	"github.com/bassosimone/vclip"
+	"github.com/bassosimone/vflag"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/webtransport-go"
+)
+
+func main() {
+	vclip.Main(context.Background(), vclip.CommandFunc(serveMain), os.Args[1:])
+}
+
+func serveMain(ctx context.Context, args []string) error {
+	var (
+		addressFlag          = "127.0.0.1"
+		allowCompressionFlag = false
+		certFlag             = "testdata/cert.pem"
+		clientCAFlag         = ""
+		contentTypeFlag      = transfer.DefaultContentType
+		cpuAffinityFlag      = ""
+		errorRateFlag        = 0.0
+		fillModeFlag         = "zero"
+		fillSeedFlag         = uint64(0)
+		geoipDBFlag          = []string{}
+		gomaxprocsFlag       = 0
+		keyFlag              = "testdata/key.pem"
+		keylogFlag           = ""
+		logFormatFlag        = "text"
+		logLevelFlag         = "info"
+		maxDurationFlag      = 60 * time.Second
+		maxSizeFlag          = ""
+		ocspStapleFlag       = ""
+		portFlag             = "4445"
+		qlogDirFlag          = ""
+		resultsOutFlag       = ""
+		staticDirFlag        = "./static/http2"
+		uploadTTLFlag        = 10 * time.Minute
+		uploadsFlag          = 1000
+	)
+
+	fset := vflag.NewFlagSet("http3-server", vflag.ExitOnError)
+	fset.StringVar(&addressFlag, 'A', "address", "Use the given IP `ADDRESS`.")
+	fset.BoolVar(&allowCompressionFlag, 0, "allow-compression", "Compress GET responses when the client accepts br, gzip, or deflate.")
+	fset.StringVar(&certFlag, 0, "cert", "Use `FILE` as the TLS certificate.")
+	fset.StringVar(&clientCAFlag, 0, "client-ca", "Require client certificates signed by the CAs in `FILE`.")
+	fset.StringVar(&contentTypeFlag, 0, "content-type", "Send GET responses with the given `TYPE`, unless overridden by ?type= (default: @DEFAULT_VALUE@).")
+	fset.StringVar(&cpuAffinityFlag, 0, "cpu-affinity", "Pin the process to the CPUs in `LIST` (e.g., 0-3,6; Linux only).")
+	fset.Float64Var(&errorRateFlag, 0, "error-rate", "Fail the given `FRACTION` of GET /api/{size} requests with 500 (e.g., 0.01).")
+	fset.StringVar(&fillModeFlag, 0, "fill-mode", "Fill GET bodies using `MODE` (zero, pattern, random, or offset).")
+	fset.Uint64Var(&fillSeedFlag, 0, "fill-seed", "Seed the random fill mode with `SEED` (default: @DEFAULT_VALUE@).")
+	fset.StringSliceVar(&geoipDBFlag, 0, "geoip-db", "Annotate transfers with the client location from the MaxMind database `FILE` (repeatable).")
+	fset.IntVar(&gomaxprocsFlag, 0, "gomaxprocs", "Set GOMAXPROCS to `N` (default: the number of usable CPUs).")
+	fset.AutoHelp('h', "help", "Print this help text and exit.")
+	fset.StringVar(&keyFlag, 0, "key", "Use `FILE` as the TLS private key.")
+	fset.StringVar(&keylogFlag, 0, "keylog", "Append TLS secrets to `FILE` for decrypting packet captures (use \"env\" for $SSLKEYLOGFILE).")
+	fset.StringVar(&logFormatFlag, 0, "log-format", "Log using `FORMAT` (text or json).")
+	fset.StringVar(&logLevelFlag, 0, "log-level", "Log messages at `LEVEL` or above (debug, info, warn, or error).")
+	fset.DurationVar(&maxDurationFlag, 0, "max-duration", "Reject duration-bounded GET requests longer than `DURATION` and stop WebTransport streams after it (default: @DEFAULT_VALUE@).")
+	fset.StringVar(&maxSizeFlag, 0, "max-size", "Reject GET, PUT, and WebTransport transfers larger than `SIZE` (e.g., 10GB; default: unlimited).")
+	fset.StringVar(&ocspStapleFlag, 0, "ocsp-staple", "Staple the DER-encoded OCSP response in `FILE` to TLS handshakes (reloaded on SIGHUP).")
+	fset.StringVar(&portFlag, 'p', "port", "Use the given UDP `PORT`.")
+	fset.StringVar(&qlogDirFlag, 0, "qlog-dir", "Write a qlog trace per connection to `DIR`.")
+	fset.StringVar(&resultsOutFlag, 0, "results-out", "Append a JSON line per completed transfer to `FILE` (or - for stdout).")
+	fset.StringVar(&staticDirFlag, 0, "static-dir", "Serve static files from `DIR`.")
+	fset.DurationVar(&uploadTTLFlag, 0, "upload-ttl", "Forget resumable uploads idle for `DURATION` (default: @DEFAULT_VALUE@).")
+	fset.IntVar(&uploadsFlag, 0, "uploads", "Track up to `N` resumable uploads at a time; zero disables (default: @DEFAULT_VALUE@).")
+	runtimex.LogFatalOnError0(envflag.Parse(fset, args))
+	runtimex.LogFatalOnError0(slogging.Setup(logFormatFlag, logLevelFlag))
+	runtimex.LogFatalOnError0(cpuaffinity.Setup(gomaxprocsFlag, cpuAffinityFlag))
+
+	if !(errorRateFlag >= 0 && errorRateFlag <= 1) {
+		log.Fatalf("http3-server: invalid error rate: %g", errorRateFlag)
+	}
+	fillReader := runtimex.LogFatalOnError1(transfer.NewFillFunc(fillModeFlag, fillSeedFlag))
+	if allowCompressionFlag && fillModeFlag != "random" {
+		slog.Warn("compressing compressible fill data; use --fill-mode random to measure compression",
+			slog.String("fillMode", fillModeFlag))
+	}
+	runtimex.LogFatalOnError2(mime.ParseMediaType(contentTypeFlag))
+
+	var maxSize int64
+	if maxSizeFlag != "" {
+		maxSize = runtimex.LogFatalOnError1(transfer.ParseSize(maxSizeFlag))
+	}
+
+	// Clients without a valid certificate fail the TLS handshake
+	// and never reach the HTTP handlers.
+	var clientCAs *x509.CertPool
+	if clientCAFlag != "" {
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(runtimex.LogFatalOnError1(os.ReadFile(clientCAFlag))) {
+			log.Fatalf("http3-server: no certificates in %s", clientCAFlag)
+		}
+	}
+
+	h := &transfer.Handler{
+		AllowCompression: allowCompressionFlag,
+		ContentType:      contentTypeFlag,
+		ErrorRate:        errorRateFlag,
+		FillReader:       fillReader,
+		MaxDuration:      maxDurationFlag,
+		MaxSize:          maxSize,
+	}
+	if resultsOutFlag != "" {
+		h.Results = runtimex.LogFatalOnError1(transfer.OpenResults(resultsOutFlag))
+	}
+	if uploadsFlag > 0 {
+		h.Uploads = transfer.NewUploads(uploadsFlag, uploadTTLFlag)
+	}
+
+	// QUIC always uses TLS 1.3.
+	caps := h.Capabilities()
+	caps.Protocols = []string{"h3"}
+	caps.TLSMinVersion = tls.VersionName(tls.VersionTLS13)
+	caps.TLSMaxVersion = tls.VersionName(tls.VersionTLS13)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /api", http.HandlerFunc(h.HandleGet))
+	mux.Handle("GET /api/{size}", http.HandlerFunc(h.HandleGet))
+	mux.Handle("GET /api/capabilities", http.HandlerFunc(caps.HandleCapabilities))
+	mux.Handle("GET /api/duration/{seconds}", http.HandlerFunc(h.HandleGetDuration))
+	mux.Handle("GET /api/ping", http.HandlerFunc(h.HandlePing))
+	mux.Handle("GET /api/status/{code}", http.HandlerFunc(h.HandleStatus))
+	mux.Handle("PUT /api", http.HandlerFunc(h.HandlePut))
+	mux.Handle("PUT /api/{size}", http.HandlerFunc(h.HandlePut))
+	mux.Handle("POST /api", http.HandlerFunc(h.HandlePut))
+	mux.Handle("POST /api/{size}", http.HandlerFunc(h.HandlePut))
+	mux.Handle("POST /api/echo", http.HandlerFunc(h.HandleEcho))
+	if h.Uploads != nil {
+		mux.Handle("HEAD /api/upload/{id}", http.HandlerFunc(h.HandleUploadHead))
+		mux.Handle("PUT /api/upload/{id}", http.HandlerFunc(h.HandleUploadPut))
+	}
+
+	var geoDB *geoip.DB
+	if len(geoipDBFlag) > 0 {
+		geoDB = runtimex.LogFatalOnError1(geoip.Open(geoipDBFlag...))
+		defer geoDB.Close()
+	}
+
+	certs := runtimex.LogFatalOnError1(certreload.New(certFlag, keyFlag, ocspStapleFlag))
+	go certs.WatchSIGHUP(ctx)
+
+	tlsConfig := &tls.Config{
+		GetCertificate: certs.GetCertificate,
+	}
+	if clientCAs != nil {
+		tlsConfig.ClientCAs = clientCAs
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	if keylogPath := keylog.Path(keylogFlag); keylogPath != "" {
+		keylogFile := runtimex.LogFatalOnError1(keylog.Open(keylogPath))
+		defer keylogFile.Close()
+		tlsConfig.KeyLogWriter = keylogFile
+	}
+
+	// The WebTransport server uses the TLS configuration as is,
+	// so we need to configure the HTTP/3 ALPN ourselves.
+	endpoint := net.JoinHostPort(addressFlag, portFlag)
+	watcher := &connWatcher{qlogDir: qlogDirFlag}
+	srv := &http3.Server{
+		Addr:      endpoint,
+		Handler:   geoDB.Wrap(mux),
+		TLSConfig: http3.ConfigureTLSConfig(tlsConfig),
+		ConnContext: func(ctx context.Context, conn *quic.Conn) context.Context {
+			watcher.watch(conn)
+			return ctx
+		},
+	}
+	if qlogDirFlag != "" {
+		runtimex.LogFatalOnError0(os.MkdirAll(qlogDirFlag, 0755))
+		slog.Info("writing qlog traces", slog.String("dir", qlogDirFlag))
+	}
+	wt := &webtransport.Server{H3: srv}
+	wh := &webTransportHandler{
+		fillReader:  fillReader,
+		maxDuration: maxDurationFlag,
+		maxSize:     maxSize,
+		server:      wt,
+	}
+	mux.Handle("CONNECT /webtransport", http.HandlerFunc(wh.handleSession))
+	mux.Handle("/", http.FileServer(http.Dir(staticDirFlag)))
+
+	pconn := runtimex.LogFatalOnError1(net.ListenPacket("udp", endpoint))
+	defer pconn.Close()
+	slog.Info("serving at", slog.String("addr", endpoint))
+	err := watcher.serve(ctx, wt, pconn)
+	slog.Info("interrupted", slog.Any("err", err))
+
+	if errors.Is(err, http.ErrServerClosed) || ctx.Err() != nil {
+		err = nil
+	}
+	runtimex.LogFatalOnError0(err)
+	return nil
+}
@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/qlog"
+	"github.com/quic-go/quic-go/qlogwriter"
+	"github.com/quic-go/webtransport-go"
+)
+
+// connWatcher logs the lifecycle of QUIC connections, including migrations.
+//
+// The zero value is ready to use.
+type connWatcher struct {
+	// qlogDir is the directory where to write qlog traces or empty.
+	qlogDir string
+}
+
+// connInfoKey is the context key for the [*connInfo] of a connection.
+type connInfoKey struct{}
+
+// connInfo is what a [connWatcher] knows about a connection.
+type connInfo struct {
+	conn       atomic.Pointer[quic.Conn]
+	connID     quic.ConnectionID
+	migrations atomic.Int64
+}
+
+// serve serves wt using the given socket until ctx is done, such that
+// both the tracer and the [*quic.Conn] see the same [*connInfo].
+func (cw *connWatcher) serve(ctx context.Context, wt *webtransport.Server, pconn net.PacketConn) error {
+	config := &quic.Config{}
+	if wt.H3.QUICConfig != nil {
+		config = wt.H3.QUICConfig.Clone()
+	}
+	config.EnableDatagrams = true
+	config.EnableStreamResetPartialDelivery = true
+	config.Tracer = cw.tracer
+
+	tr := &quic.Transport{Conn: pconn, ConnContext: cw.connContext}
+	defer tr.Close()
+	ln, err := tr.ListenEarly(wt.H3.TLSConfig, config)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	for {
+		conn, err := ln.Accept(ctx)
+		if err != nil {
+			// Close the connections while the socket is still open,
+			// such that clients receive the CONNECTION_CLOSE frames.
+			wt.Close()
+			return err
+		}
+		go func() {
+			if err := wt.ServeQUICConn(conn); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Warn("conn failed", slog.String("remote", conn.RemoteAddr().String()), slog.Any("err", err))
+			}
+		}()
+	}
+}
+
+// connContext implements [quic.Transport.ConnContext].
+func (cw *connWatcher) connContext(ctx context.Context, _ *quic.ClientInfo) (context.Context, error) {
+	return context.WithValue(ctx, connInfoKey{}, &connInfo{}), nil
+}
+
+// tracer implements [quic.Config.Tracer] to learn connection IDs and migrations.
+func (cw *connWatcher) tracer(ctx context.Context, isClient bool, connID quic.ConnectionID) qlogwriter.Trace {
+	info, ok := ctx.Value(connInfoKey{}).(*connInfo)
+	if !ok {
+		return nil
+	}
+	info.connID = connID
+	trace := &migrationTrace{info: info}
+	if cw.qlogDir != "" {
+		trace.Trace = cw.qlogTrace(isClient, connID)
+	}
+	return trace
+}
+
+// qlogTrace returns a trace writing to a qlog file named after connID,
+// like [qlog.DefaultConnectionTracer], or nil on failure.
+func (cw *connWatcher) qlogTrace(isClient bool, connID quic.ConnectionID) qlogwriter.Trace {
+	label := "server"
+	if isClient {
+		label = "client"
+	}
+	path := filepath.Join(cw.qlogDir, fmt.Sprintf("%s_%s.sqlog", connID, label))
+	filep, err := os.Create(path)
+	if err != nil {
+		slog.Warn("cannot create qlog file", slog.String("path", path), slog.Any("err", err))
+		return nil
+	}
+	trace := qlogwriter.NewConnectionFileSeq(&bufferedFile{bufio.NewWriter(filep), filep},
+		isClient, connID, []string{qlog.EventSchema})
+	go trace.Run()
+	return trace
+}
+
+// watch logs that conn is new and, once it is closed, its migrations.
+func (cw *connWatcher) watch(conn *quic.Conn) {
+	info, ok := conn.Context().Value(connInfoKey{}).(*connInfo)
+	if !ok {
+		return
+	}
+	info.conn.Store(conn)
+	cid := slog.String("cid", info.connID.String())
+	slog.Info("conn new", cid,
+		slog.String("remote", conn.RemoteAddr().String()),
+		slog.String("alpn", conn.ConnectionState().TLS.NegotiatedProtocol),
+	)
+	context.AfterFunc(conn.Context(), func() {
+		slog.Info("conn closed", cid,
+			slog.String("remote", conn.RemoteAddr().String()),
+			slog.Int64("migrations", info.migrations.Load()),
+		)
+	})
+}
+
+// migrationTrace is a [qlogwriter.Trace] detecting migrations and
+// optionally wrapping a qlog file trace.
+type migrationTrace struct {
+	qlogwriter.Trace
+	info *connInfo
+}
+
+// AddProducer implements [qlogwriter.Trace].
+func (t *migrationTrace) AddProducer() qlogwriter.Recorder {
+	recorder := &migrationRecorder{info: t.info}
+	if t.Trace != nil {
+		recorder.Recorder = t.Trace.AddProducer()
+	}
+	return recorder
+}
+
+// SupportsSchemas implements [qlogwriter.Trace].
+func (t *migrationTrace) SupportsSchemas(schema string) bool {
+	return t.Trace != nil && t.Trace.SupportsSchemas(schema)
+}
+
+// migrationRecorder is a [qlogwriter.Recorder] logging a migration at the
+// first event after the connection starts using a new remote address.
+type migrationRecorder struct {
+	qlogwriter.Recorder
+	info   *connInfo
+	mu     sync.Mutex
+	remote net.Addr
+}
+
+// RecordEvent implements [qlogwriter.Recorder].
+func (r *migrationRecorder) RecordEvent(event qlogwriter.Event) {
+	if r.Recorder != nil {
+		r.Recorder.RecordEvent(event)
+	}
+	conn := r.info.conn.Load()
+	if conn == nil {
+		return
+	}
+	remote := conn.RemoteAddr()
+	r.mu.Lock()
+	previous := r.remote
+	r.remote = remote
+	r.mu.Unlock()
+
+	// quic-go replaces the address when migrating, so comparing the
+	// pointers first avoids formatting addresses for each event.
+	if previous == nil || previous == remote || previous.String() == remote.String() {
+		return
+	}
+	r.info.migrations.Add(1)
+	slog.Info("conn migrated", slog.String("cid", r.info.connID.String()),
+		slog.String("from", previous.String()),
+		slog.String("to", remote.String()),
+	)
+}
+
+// Close implements [qlogwriter.Recorder].
+func (r *migrationRecorder) Close() error {
+	if r.Recorder != nil {
+		return r.Recorder.Close()
+	}
+	return nil
+}
+
+// bufferedFile is an [io.WriteCloser] buffering writes to a file.
+type bufferedFile struct {
+	*bufio.Writer
+	filep *os.File
+}
+
+// Close flushes the buffer and closes the file.
+func (bf *bufferedFile) Close() error {
+	return errors.Join(bf.Flush(), bf.filep.Close())
+}
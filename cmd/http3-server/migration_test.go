@@ -0,0 +1,182 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/webtransport-go"
+)
+
+// lockedBuffer is a [bytes.Buffer] safe for concurrent use.
+type lockedBuffer struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (lb *lockedBuffer) Write(data []byte) (int, error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	return lb.buf.Write(data)
+}
+
+func (lb *lockedBuffer) String() string {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	return lb.buf.String()
+}
+
+// newTestCert returns a self-signed certificate for 127.0.0.1.
+func newTestCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+func TestConnWatcherMigration(t *testing.T) {
+	logs := &lockedBuffer{}
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(logs, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	// The handler sends half of the body, then waits for the client to
+	// move to a new socket before sending the other half.
+	const half = 1 << 20
+	migrated := make(chan struct{})
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write(make([]byte, half))
+		http.NewResponseController(rw).Flush()
+		select {
+		case <-migrated:
+		case <-req.Context().Done():
+			return
+		}
+		rw.Write(make([]byte, half))
+	})
+
+	cert, pool := newTestCert(t)
+	watcher := &connWatcher{}
+	wt := &webtransport.Server{H3: &http3.Server{
+		Handler:   handler,
+		TLSConfig: http3.ConfigureTLSConfig(&tls.Config{Certificates: []tls.Certificate{cert}}),
+		ConnContext: func(ctx context.Context, conn *quic.Conn) context.Context {
+			watcher.watch(conn)
+			return ctx
+		},
+	}}
+	pconn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pconn.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- watcher.serve(ctx, wt, pconn) }()
+	defer func() {
+		cancel()
+		<-served
+	}()
+
+	listen := func() *quic.Transport {
+		udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { udpConn.Close() })
+		tr := &quic.Transport{Conn: udpConn}
+		t.Cleanup(func() { tr.Close() })
+		return tr
+	}
+	tlsConfig := &tls.Config{RootCAs: pool, NextProtos: []string{http3.NextProtoH3}}
+	conn, err := listen().Dial(ctx, pconn.LocalAddr(), tlsConfig, &quic.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.CloseWithError(0, "")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://127.0.0.1/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http3.Transport{}).NewClientConn(conn).RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if _, err := io.ReadFull(resp.Body, make([]byte, half)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rebind the client to a new socket in the middle of the GET.
+	path, err := conn.AddPath(listen())
+	if err != nil {
+		t.Fatal(err)
+	}
+	probeCtx, probeCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer probeCancel()
+	if err := path.Probe(probeCtx); err != nil {
+		t.Fatal(err)
+	}
+	if err := path.Switch(); err != nil {
+		t.Fatal(err)
+	}
+	close(migrated)
+
+	count, err := io.Copy(io.Discard, resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != half {
+		t.Fatalf("got %d bytes after migrating, want %d", count, half)
+	}
+
+	// The server logs the migration when it starts using the new path.
+	for deadline := time.Now().Add(5 * time.Second); !strings.Contains(logs.String(), "conn migrated"); {
+		if time.Now().After(deadline) {
+			t.Fatalf("no migration in logs:\n%s", logs.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, line := range strings.Split(logs.String(), "\n") {
+		if strings.Contains(line, "conn new") || strings.Contains(line, "conn migrated") {
+			if strings.Contains(line, "cid= ") || !strings.Contains(line, "cid=") {
+				t.Fatalf("missing connection ID: %s", line)
+			}
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bassosimone/2026-02-js-perf/internal/humanize"
+	"github.com/bassosimone/2026-02-js-perf/internal/infinite"
+	"github.com/bassosimone/2026-02-js-perf/internal/transfer"
+	"github.com/quic-go/webtransport-go"
+)
+
+// maxSizeRequestLength bounds the size request sent on download streams.
+const maxSizeRequestLength = 64
+
+// webTransportHandler implements the /webtransport endpoint.
+type webTransportHandler struct {
+	// fillReader returns the reader producing download bodies.
+	fillReader func(offset int64) io.Reader
+
+	// maxDuration bounds the duration of each stream; zero means unlimited.
+	maxDuration time.Duration
+
+	// maxSize bounds the size of each stream; zero means unlimited.
+	maxSize int64
+
+	// server is the WebTransport server upgrading sessions.
+	server *webtransport.Server
+}
+
+// handleSession handles CONNECT /webtransport by establishing a session
+// and serving its streams and datagrams in the background.
+func (wh *webTransportHandler) handleSession(rw http.ResponseWriter, req *http.Request) {
+	sess, err := wh.server.Upgrade(rw, req)
+	if err != nil {
+		slog.Warn("webtransport upgrade failed", slog.Any("err", err), slog.String("remote", req.RemoteAddr))
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	remote := req.RemoteAddr
+	slog.Info("webtransport session", slog.String("remote", remote))
+	context.AfterFunc(sess.Context(), func() {
+		slog.Info("webtransport session closed", slog.String("remote", remote))
+	})
+	go wh.acceptDownloads(sess, remote)
+	go wh.acceptUploads(sess, remote)
+	go wh.echoDatagrams(sess, remote)
+}
+
+// acceptDownloads serves the bidirectional streams of sess until it ends.
+func (wh *webTransportHandler) acceptDownloads(sess *webtransport.Session, remote string) {
+	for {
+		str, err := sess.AcceptStream(sess.Context())
+		if err != nil {
+			return
+		}
+		go wh.serveDownload(str, remote)
+	}
+}
+
+// serveDownload writes the number of bytes requested by the client.
+func (wh *webTransportHandler) serveDownload(str *webtransport.Stream, remote string) {
+	defer str.Close()
+	request, err := io.ReadAll(io.LimitReader(str, maxSizeRequestLength))
+	if err != nil {
+		slog.Info("webtransport download failed", slog.Any("err", err), slog.String("remote", remote))
+		return
+	}
+	size, err := transfer.ParseSize(strings.TrimSpace(string(request)))
+	if err == nil && wh.maxSize > 0 && size > wh.maxSize {
+		err = errStreamTooLarge
+	}
+	if err != nil {
+		slog.Info("webtransport download rejected", slog.Any("err", err), slog.String("remote", remote))
+		str.CancelWrite(0)
+		return
+	}
+	slog.Info("webtransport download", slog.Int64("count", size), slog.String("remote", remote))
+	t0 := time.Now()
+	if wh.maxDuration > 0 {
+		str.SetWriteDeadline(t0.Add(wh.maxDuration))
+	}
+	count, err := io.Copy(str, infinite.LimitReader(wh.fillReader(0), size))
+	logTransfer("webtransport download done", count, err, time.Since(t0), remote)
+}
+
+// acceptUploads serves the unidirectional streams of sess until it ends.
+func (wh *webTransportHandler) acceptUploads(sess *webtransport.Session, remote string) {
+	for {
+		str, err := sess.AcceptUniStream(sess.Context())
+		if err != nil {
+			return
+		}
+		go wh.serveUpload(str, remote)
+	}
+}
+
+// errStreamTooLarge indicates that a stream exceeds the maximum size.
+var errStreamTooLarge = errors.New("stream exceeds the maximum size")
+
+// serveUpload reads and discards the bytes sent by the client.
+func (wh *webTransportHandler) serveUpload(str *webtransport.ReceiveStream, remote string) {
+	t0 := time.Now()
+	if wh.maxDuration > 0 {
+		str.SetReadDeadline(t0.Add(wh.maxDuration))
+	}
+	var body io.Reader = str
+	if wh.maxSize > 0 {
+		body = io.LimitReader(str, wh.maxSize+1)
+	}
+	count, err := io.Copy(io.Discard, body)
+	if wh.maxSize > 0 && count > wh.maxSize {
+		str.CancelRead(0)
+		count, err = wh.maxSize, errStreamTooLarge
+	}
+	logTransfer("webtransport upload done", count, err, time.Since(t0), remote)
+}
+
+// echoDatagrams sends back the datagrams received on sess until it ends.
+func (wh *webTransportHandler) echoDatagrams(sess *webtransport.Session, remote string) {
+	var received, echoed int
+	defer func() {
+		if received > 0 {
+			slog.Info("webtransport datagrams", slog.Int("received", received),
+				slog.Int("echoed", echoed), slog.String("remote", remote))
+		}
+	}()
+	for {
+		data, err := sess.ReceiveDatagram(sess.Context())
+		if err != nil {
+			return
+		}
+		received++
+		if sess.SendDatagram(data) == nil {
+			echoed++
+		}
+	}
+}
+
+// logTransfer logs the outcome of a WebTransport stream transfer.
+func logTransfer(msg string, count int64, err error, elapsed time.Duration, remote string) {
+	bitsPerSecond := transfer.Speed(count, elapsed)
+	slog.Info(msg,
+		slog.Int64("bytes", count),
+		slog.Any("err", err),
+		slog.Duration("elapsed", elapsed),
+		slog.String("speed", humanize.SI(bitsPerSecond, "bit/s")),
+		slog.Float64("mbps", bitsPerSecond/1e6),
+		slog.String("remote", remote),
+	)
+}
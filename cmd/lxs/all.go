@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/bassosimone/runtimex"
+	"github.com/bassosimone/vflag"
+)
+
+func serveAllMain(ctx context.Context, args []string) error {
+	var (
+		addressFlag   = "127.0.0.1"
+		http1PortFlag = "4443"
+		http3PortFlag = "4445"
+		logFormatFlag = "text"
+		logLevelFlag  = "info"
+		ndt7PortFlag  = "4567"
+		noBuildFlag   = false
+		reuseCertFlag = false
+	)
+
+	fset := vflag.NewFlagSet("lxs serve all", vflag.ExitOnError)
+	fset.StringVar(&addressFlag, 'A', "address", "Use the given IP `ADDRESS`.")
+	fset.AutoHelp('h', "help", "Print this help text and exit.")
+	fset.StringVar(&http1PortFlag, 0, "http1-port", "Serve HTTP/1.1 using the given TCP `PORT` (default: @DEFAULT_VALUE@).")
+	fset.StringVar(&http3PortFlag, 0, "http3-port", "Serve HTTP/3 using the given UDP `PORT` (default: @DEFAULT_VALUE@).")
+	fset.StringVar(&logFormatFlag, 0, "log-format", "Log using `FORMAT` (text or json).")
+	fset.StringVar(&logLevelFlag, 0, "log-level", "Log messages at `LEVEL` or above (debug, info, warn, or error).")
+	fset.StringVar(&ndt7PortFlag, 0, "ndt7-port", "Serve ndt7 using the given TCP `PORT` (default: @DEFAULT_VALUE@).")
+	fset.BoolVar(&noBuildFlag, 0, "no-build", "Skip building and run the already-built binaries.")
+	fset.BoolVar(&reuseCertFlag, 0, "reuse-cert", "Skip gencert when testdata/cert.pem and testdata/key.pem are still valid.")
+	globalConfig.apply(fset)
+	runtimex.PanicOnError0(fset.Parse(args))
+
+	r := &runner{ctx: ctx}
+	if !noBuildFlag {
+		r.run("go build -v ./cmd/gencert")
+		r.run("go build -v ./cmd/http1-server")
+		r.run("go build -v ./cmd/http3-server")
+		r.run("go build -v ./cmd/ndt7-server")
+	}
+	if !reuseCertFlag || !reusableCert(addressFlag) {
+		r.run("./gencert --ip-addr %s", addressFlag)
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	// The Rust HTTP/2 server requires its own toolchain, so we leave
+	// it to `lxs serve http2` for the time being.
+	logArgs := fmt.Sprintf("--log-format %s --log-level %s", logFormatFlag, logLevelFlag)
+	services := []struct {
+		name    string
+		proto   string
+		port    string
+		cmdline string
+	}{{
+		name:    "http1",
+		proto:   "HTTP/1.1+TLS",
+		port:    http1PortFlag,
+		cmdline: fmt.Sprintf("./http1-server -A %s -p %s %s", addressFlag, http1PortFlag, logArgs),
+	}, {
+		name:    "http3",
+		proto:   "HTTP/3+QUIC",
+		port:    http3PortFlag,
+		cmdline: fmt.Sprintf("./http3-server -A %s -p %s %s", addressFlag, http3PortFlag, logArgs),
+	}, {
+		name:    "ndt7",
+		proto:   "WebSocket+TLS",
+		port:    ndt7PortFlag,
+		cmdline: fmt.Sprintf("./ndt7-server serve -A %s -p %s %s", addressFlag, ndt7PortFlag, logArgs),
+	}}
+
+	// The first server to exit, including because of Ctrl-C, stops
+	// the others, such that we never leave a partial stack running.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errch := make(chan error, len(services))
+	for _, svc := range services {
+		fmt.Fprintf(os.Stderr, "+ # %s: %s at %s\n", svc.name, svc.proto, net.JoinHostPort(addressFlag, svc.port))
+		go func() {
+			defer cancel()
+			errch <- run(ctx, "%s", svc.cmdline)
+		}()
+	}
+	var errs []error
+	for range services {
+		errs = append(errs, <-errch)
+	}
+	return errors.Join(errs...)
+}
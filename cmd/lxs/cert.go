@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// certDir is the directory where gencert writes cert.pem and key.pem.
+const certDir = "testdata"
+
+// reusableCert returns true if cert.pem and key.pem exist in [certDir],
+// form a valid key pair, are currently valid, and cover address.
+func reusableCert(address string) bool {
+	certPath := filepath.Join(certDir, "cert.pem")
+	keyPath := filepath.Join(certDir, "key.pem")
+	pair, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return false
+	}
+	now := time.Now()
+	if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return false
+	}
+	if cert.VerifyHostname(address) != nil {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "+ # reusing %s and %s\n", certPath, keyPath)
+	return true
+}
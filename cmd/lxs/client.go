@@ -0,0 +1,260 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bassosimone/2026-02-js-perf/internal/humanize"
+	"github.com/bassosimone/2026-02-js-perf/internal/infinite"
+	"github.com/bassosimone/2026-02-js-perf/internal/slogging"
+	"github.com/bassosimone/2026-02-js-perf/internal/transfer"
+	"github.com/bassosimone/runtimex"
+	"github.com/bassosimone/vflag"
+)
+
+// clientSummary is the JSON summary printed by `lxs client`.
+type clientSummary struct {
+	URL           string             `json:"url"`
+	Method        string             `json:"method"`
+	Parallel      int                `json:"parallel"`
+	Size          int64              `json:"size"`
+	Warmup        *clientPhase       `json:"warmup,omitempty"`
+	Bytes         int64              `json:"bytes"`
+	Elapsed       float64            `json:"elapsedSeconds"`
+	BitsPerSecond float64            `json:"bitsPerSecond"`
+	Conns         []clientConnResult `json:"conns"`
+}
+
+// clientPhase contains the aggregate results of the warm-up phase.
+type clientPhase struct {
+	Bytes         int64   `json:"bytes"`
+	Elapsed       float64 `json:"elapsedSeconds"`
+	BitsPerSecond float64 `json:"bitsPerSecond"`
+}
+
+// clientConnResult contains the steady-state results of a single connection.
+type clientConnResult struct {
+	WarmupBytes   int64   `json:"warmupBytes"`
+	Bytes         int64   `json:"bytes"`
+	Requests      int     `json:"requests"`
+	Elapsed       float64 `json:"elapsedSeconds"`
+	BitsPerSecond float64 `json:"bitsPerSecond"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	count *atomic.Int64
+	r     io.Reader
+}
+
+// Read implements [io.Reader].
+func (cr *countingReader) Read(buf []byte) (int, error) {
+	count, err := cr.r.Read(buf)
+	cr.count.Add(int64(count))
+	return count, err
+}
+
+func clientMain(ctx context.Context, args []string) error {
+	var (
+		addressFlag   = "127.0.0.1"
+		durationFlag  = 10 * time.Second
+		harFlag       = ""
+		insecureFlag  = false
+		logFormatFlag = "text"
+		logLevelFlag  = "info"
+		methodFlag    = "GET"
+		parallelFlag  = 1
+		portFlag      = "4443"
+		sizeFlag      = "1GB"
+		warmupFlag    = time.Duration(0)
+	)
+
+	fset := vflag.NewFlagSet("lxs client", vflag.ExitOnError)
+	fset.StringVar(&addressFlag, 'A', "address", "Connect to the given IP `ADDRESS`.")
+	fset.DurationVar(&durationFlag, 'd', "duration", "Measure the steady state for `DURATION` (default: @DEFAULT_VALUE@).")
+	fset.StringVar(&harFlag, 0, "har", "Replay the GET and PUT requests in the HAR `FILE` once rather than repeating a request.")
+	fset.AutoHelp('h', "help", "Print this help text and exit.")
+	fset.BoolVar(&insecureFlag, 'k', "insecure", "Skip TLS certificate verification (e.g., for gencert output).")
+	fset.StringVar(&logFormatFlag, 0, "log-format", "Log using `FORMAT` (text or json).")
+	fset.StringVar(&logLevelFlag, 0, "log-level", "Log messages at `LEVEL` or above (debug, info, warn, or error).")
+	fset.StringVar(&methodFlag, 'm', "method", "Use the given `METHOD` (GET or PUT).")
+	fset.IntVar(&parallelFlag, 'P', "parallel", "Use `N` parallel connections (default: @DEFAULT_VALUE@).")
+	fset.StringVar(&portFlag, 'p', "port", "Connect to the given TCP `PORT`.")
+	fset.StringVar(&sizeFlag, 's', "size", "Transfer `SIZE` bytes per request (e.g., 1GB; default: @DEFAULT_VALUE@).")
+	fset.DurationVar(&warmupFlag, 'w', "warmup", "Exclude the first `DURATION` from the steady-state throughput.")
+	globalConfig.apply(fset)
+	runtimex.PanicOnError0(fset.Parse(args))
+	runtimex.LogFatalOnError0(slogging.Setup(logFormatFlag, logLevelFlag))
+
+	methodFlag = strings.ToUpper(methodFlag)
+	switch methodFlag {
+	case http.MethodGet, http.MethodPut:
+	default:
+		log.Fatalf("lxs client: invalid method: %s", methodFlag)
+	}
+	if parallelFlag <= 0 {
+		log.Fatalf("lxs client: invalid parallelism: %d", parallelFlag)
+	}
+	if harFlag != "" {
+		base := &url.URL{Scheme: "https", Host: net.JoinHostPort(addressFlag, portFlag)}
+		return clientHAR(ctx, harFlag, base, parallelFlag, insecureFlag)
+	}
+	size := runtimex.LogFatalOnError1(transfer.ParseSize(sizeFlag))
+
+	URL := fmt.Sprintf("https://%s/api/%d", net.JoinHostPort(addressFlag, portFlag), size)
+	slog.Info("client", slog.String("method", methodFlag), slog.String("url", URL),
+		slog.Int("parallel", parallelFlag), slog.Duration("warmup", warmupFlag),
+		slog.Duration("duration", durationFlag))
+
+	ctx, cancel := context.WithTimeout(ctx, warmupFlag+durationFlag)
+	defer cancel()
+
+	t0 := time.Now()
+	counts := make([]atomic.Int64, parallelFlag)
+	results := make([]clientConnResult, parallelFlag)
+	endTimes := make([]time.Time, parallelFlag)
+	warmupCounts := make([]int64, parallelFlag)
+	wg := &sync.WaitGroup{}
+	for idx := range parallelFlag {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[idx], endTimes[idx] = clientConn(ctx, methodFlag, URL, size, insecureFlag, &counts[idx])
+		}()
+	}
+
+	// Bytes transferred during the warm-up are excluded from the steady
+	// state, so we can observe the effect of TCP slow start explicitly.
+	var warmup *clientPhase
+	if warmupFlag > 0 {
+		timer := time.NewTimer(warmupFlag)
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+		timer.Stop()
+		warmup = &clientPhase{}
+		for idx := range counts {
+			warmupCounts[idx] = counts[idx].Load()
+			warmup.Bytes += warmupCounts[idx]
+		}
+	}
+	steadyStart := time.Now()
+	if warmup != nil {
+		warmup.Elapsed = steadyStart.Sub(t0).Seconds()
+		warmup.BitsPerSecond = speed(warmup.Bytes, steadyStart.Sub(t0))
+	}
+	wg.Wait()
+	elapsed := time.Since(steadyStart)
+
+	summary := &clientSummary{
+		URL:      URL,
+		Method:   methodFlag,
+		Parallel: parallelFlag,
+		Size:     size,
+		Warmup:   warmup,
+		Elapsed:  elapsed.Seconds(),
+		Conns:    results,
+	}
+	for idx := range results {
+		result := &results[idx]
+		result.WarmupBytes = warmupCounts[idx]
+		result.Bytes = counts[idx].Load() - result.WarmupBytes
+		steady := endTimes[idx].Sub(steadyStart)
+		result.Elapsed = max(steady, 0).Seconds()
+		result.BitsPerSecond = speed(result.Bytes, steady)
+		summary.Bytes += result.Bytes
+	}
+	summary.BitsPerSecond = speed(summary.Bytes, elapsed)
+	if warmup != nil {
+		slog.Info("client warmup", slog.Int64("bytes", warmup.Bytes),
+			slog.String("speed", humanize.SI(warmup.BitsPerSecond, "bit/s")))
+	}
+	slog.Info("client done", slog.Int64("bytes", summary.Bytes), slog.Duration("elapsed", elapsed),
+		slog.String("speed", humanize.SI(summary.BitsPerSecond, "bit/s")))
+
+	data := runtimex.LogFatalOnError1(json.MarshalIndent(summary, "", "  "))
+	fmt.Fprintf(os.Stdout, "%s\n", data)
+	return nil
+}
+
+// clientConn repeatedly performs requests over a single connection until ctx
+// is done, adding the transferred body bytes to count, and returns when it stopped.
+func clientConn(ctx context.Context, method, URL string,
+	size int64, insecure bool, count *atomic.Int64) (clientConnResult, time.Time) {
+	txp := &http.Transport{
+		MaxConnsPerHost: 1,
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+	}
+	defer txp.CloseIdleConnections()
+	client := &http.Client{Transport: txp}
+
+	var result clientConnResult
+	for ctx.Err() == nil {
+		err := clientRequest(ctx, client, method, URL, size, count)
+		if err != nil {
+			if ctx.Err() == nil {
+				result.Error = err.Error()
+			}
+			break
+		}
+		result.Requests++
+	}
+	return result, time.Now()
+}
+
+// speed returns the speed in bit/s of transferring count bytes in elapsed.
+func speed(count int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(count) * 8 / elapsed.Seconds()
+}
+
+// errUnexpectedStatus indicates that the server returned an unexpected status code.
+var errUnexpectedStatus = errors.New("unexpected status code")
+
+// clientRequest performs a single request, adding the transferred body bytes to count.
+func clientRequest(ctx context.Context, client *http.Client,
+	method, URL string, size int64, count *atomic.Int64) error {
+	var body io.Reader
+	if method == http.MethodPut {
+		body = &countingReader{count: count, r: infinite.LimitReader(infinite.Reader{}, size)}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, URL, body)
+	if err != nil {
+		return err
+	}
+	if method == http.MethodPut {
+		req.ContentLength = size
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%w: %d", errUnexpectedStatus, resp.StatusCode)
+	}
+	if method == http.MethodGet {
+		_, err = io.Copy(io.Discard, &countingReader{count: count, r: resp.Body})
+	}
+	return err
+}
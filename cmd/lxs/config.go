@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/bassosimone/vflag"
+	"gopkg.in/yaml.v3"
+)
+
+// config contains the flag values loaded from the --config file.
+//
+// A nil [*config] is valid and contains no values.
+type config struct {
+	// global contains the values applying to all subcommands.
+	global map[string][]string
+
+	// commands contains the values applying to a specific subcommand.
+	commands map[string]map[string][]string
+}
+
+// globalConfig is the config loaded by main, if any.
+var globalConfig *config
+
+// loadConfig reads and parses the config at path.
+func loadConfig(path string) (*config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	cfg := &config{global: map[string][]string{}, commands: map[string]map[string][]string{}}
+	for key, value := range raw {
+		section, ok := value.(map[string]any)
+		if !ok {
+			cfg.global[key] = configValues(value)
+			continue
+		}
+		cfg.commands[key] = map[string][]string{}
+		for name, value := range section {
+			cfg.commands[key][name] = configValues(value)
+		}
+	}
+	return cfg, nil
+}
+
+// configValues converts a YAML scalar or list to flag values.
+func configValues(value any) []string {
+	list, ok := value.([]any)
+	if !ok {
+		return []string{fmt.Sprint(value)}
+	}
+	values := make([]string, 0, len(list))
+	for _, entry := range list {
+		values = append(values, fmt.Sprint(entry))
+	}
+	return values
+}
+
+// apply sets the flags of fset from the config before parsing the command line.
+func (cfg *config) apply(fset *vflag.FlagSet) {
+	if cfg == nil {
+		return
+	}
+	command := strings.TrimPrefix(fset.ProgramName, "lxs ")
+	for _, flag := range fset.LongFlags {
+		values, found := cfg.commands[command][flag.Name]
+		if !found {
+			values, found = cfg.global[flag.Name]
+		}
+		if !found {
+			continue
+		}
+		for _, value := range values {
+			if err := flag.Value.Set(value); err != nil {
+				log.Fatalf("lxs: config: invalid value for --%s: %s", flag.Name, err.Error())
+			}
+		}
+	}
+}
+
+// extractConfigFlag removes a leading `--config FILE` or `--config=FILE`
+// from args and returns the remaining args and the file, if any.
+func extractConfigFlag(args []string) ([]string, string) {
+	switch {
+	case len(args) >= 1 && strings.HasPrefix(args[0], "--config="):
+		return args[1:], strings.TrimPrefix(args[0], "--config=")
+	case len(args) >= 2 && args[0] == "--config":
+		return args[2:], args[1]
+	default:
+		return args, ""
+	}
+}
@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bassosimone/2026-02-js-perf/internal/humanize"
+	"github.com/bassosimone/runtimex"
+)
+
+// harFile contains the HAR fields we need to replay requests.
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				Method   string `json:"method"`
+				URL      string `json:"url"`
+				BodySize int64  `json:"bodySize"`
+				PostData *struct {
+					Text string `json:"text"`
+				} `json:"postData"`
+			} `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// harRequest is a request to replay.
+type harRequest struct {
+	method string
+	URL    string
+	size   int64
+}
+
+// harSummary is the JSON summary printed by `lxs client --har`.
+type harSummary struct {
+	File          string  `json:"file"`
+	Target        string  `json:"target"`
+	Parallel      int     `json:"parallel"`
+	Requests      int     `json:"requests"`
+	Skipped       int     `json:"skipped"`
+	Errors        int64   `json:"errors"`
+	Bytes         int64   `json:"bytes"`
+	Elapsed       float64 `json:"elapsedSeconds"`
+	BitsPerSecond float64 `json:"bitsPerSecond"`
+}
+
+// loadHAR returns the GET and PUT requests in the HAR file at path,
+// rewritten to target the given base URL, and the number of skipped
+// entries using other methods.
+func loadHAR(path string, base *url.URL) ([]harRequest, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, 0, err
+	}
+	var (
+		requests []harRequest
+		skipped  int
+	)
+	for _, entry := range har.Log.Entries {
+		method := entry.Request.Method
+		if method != http.MethodGet && method != http.MethodPut {
+			skipped++
+			continue
+		}
+		URL, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			return nil, 0, err
+		}
+		URL.Scheme, URL.Host = base.Scheme, base.Host
+
+		// Browsers record zero or -1 as bodySize for some bodies (e.g.,
+		// blobs), in which case we fall back to the recorded text.
+		size := max(entry.Request.BodySize, 0)
+		if size == 0 && entry.Request.PostData != nil {
+			size = int64(len(entry.Request.PostData.Text))
+		}
+		requests = append(requests, harRequest{method: method, URL: URL.String(), size: size})
+	}
+	return requests, skipped, nil
+}
+
+// clientHAR replays the GET and PUT requests in the HAR file at path
+// against base as fast as possible using parallel connections, ignoring
+// the recorded timing, and prints the aggregate throughput.
+func clientHAR(ctx context.Context, path string, base *url.URL, parallel int, insecure bool) error {
+	requests, skipped, err := loadHAR(path, base)
+	if err != nil {
+		return err
+	}
+	slog.Info("client har", slog.String("file", path), slog.String("target", base.String()),
+		slog.Int("requests", len(requests)), slog.Int("skipped", skipped), slog.Int("parallel", parallel))
+
+	txp := &http.Transport{
+		MaxConnsPerHost: parallel,
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+	}
+	defer txp.CloseIdleConnections()
+	client := &http.Client{Transport: txp}
+
+	var (
+		count    atomic.Int64
+		failures atomic.Int64
+		next     atomic.Int64
+	)
+	t0 := time.Now()
+	wg := &sync.WaitGroup{}
+	for range parallel {
+		wg.Go(func() {
+			for ctx.Err() == nil {
+				idx := int(next.Add(1)) - 1
+				if idx >= len(requests) {
+					return
+				}
+				hr := requests[idx]
+				if err := clientRequest(ctx, client, hr.method, hr.URL, hr.size, &count); err != nil {
+					slog.Warn("client har request failed", slog.String("method", hr.method),
+						slog.String("url", hr.URL), slog.Any("err", err))
+					failures.Add(1)
+				}
+			}
+		})
+	}
+	wg.Wait()
+	elapsed := time.Since(t0)
+
+	summary := &harSummary{
+		File:          path,
+		Target:        base.String(),
+		Parallel:      parallel,
+		Requests:      len(requests),
+		Skipped:       skipped,
+		Errors:        failures.Load(),
+		Bytes:         count.Load(),
+		Elapsed:       elapsed.Seconds(),
+		BitsPerSecond: speed(count.Load(), elapsed),
+	}
+	slog.Info("client har done", slog.Int64("bytes", summary.Bytes), slog.Int64("errors", summary.Errors),
+		slog.Duration("elapsed", elapsed), slog.String("speed", humanize.SI(summary.BitsPerSecond, "bit/s")))
+
+	data := runtimex.LogFatalOnError1(json.MarshalIndent(summary, "", "  "))
+	fmt.Fprintf(os.Stdout, "%s\n", data)
+	return nil
+}
@@ -4,28 +4,51 @@ package main
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/bassosimone/runtimex"
 	"github.com/bassosimone/vflag"
+	"github.com/kballard/go-shellquote"
 )
 
 func serveHTTP1Main(ctx context.Context, args []string) error {
 	var (
-		addressFlag = "127.0.0.1"
-		portFlag    = "4443"
+		addressFlag   = "127.0.0.1"
+		clientCAFlag  = ""
+		logFormatFlag = "text"
+		logLevelFlag  = "info"
+		noBuildFlag   = false
+		portFlag      = "4443"
+		reuseCertFlag = false
 	)
 
 	fset := vflag.NewFlagSet("lxs serve http1", vflag.ExitOnError)
 	fset.StringVar(&addressFlag, 'A', "address", "Use the given IP `ADDRESS`.")
+	fset.StringVar(&clientCAFlag, 0, "client-ca", "Require client certificates signed by the CAs in `FILE`.")
 	fset.AutoHelp('h', "help", "Print this help text and exit.")
+	fset.StringVar(&logFormatFlag, 0, "log-format", "Log using `FORMAT` (text or json).")
+	fset.StringVar(&logLevelFlag, 0, "log-level", "Log messages at `LEVEL` or above (debug, info, warn, or error).")
+	fset.BoolVar(&noBuildFlag, 0, "no-build", "Skip building and run the already-built binaries.")
 	fset.StringVar(&portFlag, 'p', "port", "Use the given TCP `PORT`.")
+	fset.BoolVar(&reuseCertFlag, 0, "reuse-cert", "Skip gencert when testdata/cert.pem and testdata/key.pem are still valid.")
+	globalConfig.apply(fset)
 	runtimex.PanicOnError0(fset.Parse(args))
 
-	mustRun("go build -v ./cmd/gencert")
-	mustRun("go build -v ./cmd/http1-server")
-
-	mustRun("./gencert --ip-addr %s", addressFlag)
-	mustRun("./http1-server -A %s -p %s", addressFlag, portFlag)
-
-	return nil
+	r := &runner{ctx: ctx}
+	if !noBuildFlag {
+		r.run("go build -v ./cmd/gencert")
+		r.run("go build -v ./cmd/http1-server")
+	}
+
+	if !reuseCertFlag || !reusableCert(addressFlag) {
+		r.run("./gencert --ip-addr %s", addressFlag)
+	}
+	cmdline := fmt.Sprintf("./http1-server -A %s -p %s --log-format %s --log-level %s",
+		addressFlag, portFlag, logFormatFlag, logLevelFlag)
+	if clientCAFlag != "" {
+		cmdline += " --client-ca " + shellquote.Join(clientCAFlag)
+	}
+	r.run("%s", cmdline)
+
+	return r.err
 }
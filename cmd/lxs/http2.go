@@ -11,22 +11,32 @@ import (
 
 func serveHTTP2Main(ctx context.Context, args []string) error {
 	var (
-		addressFlag = "127.0.0.1"
-		portFlag    = "4444"
+		addressFlag   = "127.0.0.1"
+		noBuildFlag   = false
+		portFlag      = "4444"
+		reuseCertFlag = false
 	)
 
 	fset := vflag.NewFlagSet("lxs serve http2", vflag.ExitOnError)
 	fset.StringVar(&addressFlag, 'A', "address", "Use the given IP `ADDRESS`.")
 	fset.AutoHelp('h', "help", "Print this help text and exit.")
+	fset.BoolVar(&noBuildFlag, 0, "no-build", "Skip building and run the already-built binaries.")
 	fset.StringVar(&portFlag, 'p', "port", "Use the given TCP `PORT`.")
+	fset.BoolVar(&reuseCertFlag, 0, "reuse-cert", "Skip gencert when testdata/cert.pem and testdata/key.pem are still valid.")
+	globalConfig.apply(fset)
 	runtimex.PanicOnError0(fset.Parse(args))
 
-	mustRun("go build -v ./cmd/gencert")
-	mustRun("cargo build --release --manifest-path cmd/http2-server/Cargo.toml")
-	mustRun("cp cmd/http2-server/target/release/http2-server .")
+	r := &runner{ctx: ctx}
+	if !noBuildFlag {
+		r.run("go build -v ./cmd/gencert")
+		r.run("cargo build --release --manifest-path cmd/http2-server/Cargo.toml")
+		r.run("cp cmd/http2-server/target/release/http2-server .")
+	}
 
-	mustRun("./gencert --ip-addr %s", addressFlag)
-	mustRun("./http2-server -A %s -p %s", addressFlag, portFlag)
+	if !reuseCertFlag || !reusableCert(addressFlag) {
+		r.run("./gencert --ip-addr %s", addressFlag)
+	}
+	r.run("./http2-server -A %s -p %s", addressFlag, portFlag)
 
-	return nil
+	return r.err
 }
@@ -6,18 +6,31 @@ import (
 	"context"
 	"os"
 
+	"github.com/bassosimone/runtimex"
 	"github.com/bassosimone/vclip"
 	"github.com/bassosimone/vflag"
 )
 
 func main() {
 	serveDisp := vclip.NewDispatcherCommand("lxs serve", vflag.ExitOnError)
+	serveDisp.AddCommand("all", vclip.CommandFunc(serveAllMain), "Run the HTTP/1.1, HTTP/3, and ndt7 services together.")
 	serveDisp.AddCommand("http1", vclip.CommandFunc(serveHTTP1Main), "Run HTTP/1.1+TLS service.")
 	serveDisp.AddCommand("http2", vclip.CommandFunc(serveHTTP2Main), "Run HTTP/2+TLS service (Rust).")
+	serveDisp.AddCommand("http3", vclip.CommandFunc(serveHTTP3Main), "Run HTTP/3+QUIC service.")
 	serveDisp.AddCommand("ndt7", vclip.CommandFunc(serveNDT7Main), "Run ndt7 service.")
 
 	disp := vclip.NewDispatcherCommand("lxs", vflag.ExitOnError)
+	disp.AddCommand("client", vclip.CommandFunc(clientMain), "Run load-generating client.")
 	disp.AddCommand("serve", serveDisp, "Run servers.")
+	disp.AddCommand("selftest", vclip.CommandFunc(selftestMain), "Run an end-to-end self test.")
+	disp.AddCommand("version", vclip.CommandFunc(versionMain), "Print build information.")
 
-	vclip.Main(context.Background(), disp, os.Args[1:])
+	// The --config flag must precede the subcommand, whose flags
+	// then override the values read from the config file.
+	args, configPath := extractConfigFlag(os.Args[1:])
+	if configPath != "" {
+		globalConfig = runtimex.LogFatalOnError1(loadConfig(configPath))
+	}
+
+	vclip.Main(context.Background(), disp, args)
 }
@@ -11,21 +11,36 @@ import (
 
 func serveNDT7Main(ctx context.Context, args []string) error {
 	var (
-		addressFlag = "127.0.0.1"
-		portFlag    = "4567"
+		addressFlag   = "127.0.0.1"
+		logFormatFlag = "text"
+		logLevelFlag  = "info"
+		noBuildFlag   = false
+		portFlag      = "4567"
+		reuseCertFlag = false
 	)
 
 	fset := vflag.NewFlagSet("lxs serve ndt7", vflag.ExitOnError)
 	fset.StringVar(&addressFlag, 'A', "address", "Use the given IP `ADDRESS`.")
 	fset.AutoHelp('h', "help", "Print this help text and exit.")
+	fset.StringVar(&logFormatFlag, 0, "log-format", "Log using `FORMAT` (text or json).")
+	fset.StringVar(&logLevelFlag, 0, "log-level", "Log messages at `LEVEL` or above (debug, info, warn, or error).")
+	fset.BoolVar(&noBuildFlag, 0, "no-build", "Skip building and run the already-built binaries.")
 	fset.StringVar(&portFlag, 'p', "port", "Use the given TCP `PORT`.")
+	fset.BoolVar(&reuseCertFlag, 0, "reuse-cert", "Skip gencert when testdata/cert.pem and testdata/key.pem are still valid.")
+	globalConfig.apply(fset)
 	runtimex.PanicOnError0(fset.Parse(args))
 
-	mustRun("go build -v ./cmd/gencert")
-	mustRun("go build -v ./cmd/ndt7-server")
+	r := &runner{ctx: ctx}
+	if !noBuildFlag {
+		r.run("go build -v ./cmd/gencert")
+		r.run("go build -v ./cmd/ndt7-server")
+	}
 
-	mustRun("./gencert --ip-addr %s", addressFlag)
-	mustRun("./ndt7-server serve -A %s -p %s", addressFlag, portFlag)
+	if !reuseCertFlag || !reusableCert(addressFlag) {
+		r.run("./gencert --ip-addr %s", addressFlag)
+	}
+	r.run("./ndt7-server serve -A %s -p %s --log-format %s --log-level %s",
+		addressFlag, portFlag, logFormatFlag, logLevelFlag)
 
-	return nil
+	return r.err
 }
@@ -3,15 +3,27 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"time"
 
 	"github.com/bassosimone/runtimex"
 	"github.com/kballard/go-shellquote"
 )
 
-func run(format string, args ...any) error {
+// runWaitDelay is how long we wait for a child to exit after
+// interrupting it before killing it.
+const runWaitDelay = 10 * time.Second
+
+// run runs the given command line, streaming its stdin, stdout, and
+// stderr, and returns an error including the command line and the exit
+// status on failure. When ctx is done (e.g., on Ctrl-C), we interrupt the
+// child, so that servers can shut down gracefully, and kill it if it does
+// not exit within [runWaitDelay].
+func run(ctx context.Context, format string, args ...any) error {
 	cmdline := fmt.Sprintf(format, args...)
 	argv, err := shellquote.Split(cmdline)
 	if err != nil {
@@ -20,14 +32,40 @@ func run(format string, args ...any) error {
 	runtimex.Assert(len(argv) > 0)
 	fmt.Fprintf(os.Stderr, "+ %s\n", cmdline)
 
-	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = runWaitDelay
 
-	return cmd.Run()
+	// A child that exits cleanly once interrupted did not fail, since
+	// that is how we stop the servers.
+	err = cmd.Run()
+	if err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmdline, err)
+	}
+	return nil
 }
 
-func mustRun(format string, args ...any) {
-	runtimex.LogFatalOnError0(run(format, args...))
+// runner runs a sequence of commands, stopping at the first failure.
+type runner struct {
+	// ctx is the context for running commands.
+	ctx context.Context
+
+	// err is the first error that occurred, if any.
+	err error
+}
+
+// run runs the given command line unless a previous command failed
+// or the context is done.
+func (r *runner) run(format string, args ...any) {
+	if r.err == nil && r.ctx.Err() == nil {
+		r.err = run(r.ctx, format, args...)
+	}
 }
@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+
+	"github.com/bassosimone/2026-02-js-perf/internal/slogging"
+	"github.com/bassosimone/2026-02-js-perf/internal/transfer"
+	"github.com/bassosimone/runtimex"
+	"github.com/bassosimone/vflag"
+)
+
+// errSelftestFailed indicates that at least one self-test check failed.
+var errSelftestFailed = errors.New("selftest failed")
+
+func selftestMain(ctx context.Context, args []string) error {
+	var (
+		logFormatFlag = "text"
+		logLevelFlag  = "warn"
+		sizeFlag      = "10MB"
+	)
+
+	fset := vflag.NewFlagSet("lxs selftest", vflag.ExitOnError)
+	fset.AutoHelp('h', "help", "Print this help text and exit.")
+	fset.StringVar(&logFormatFlag, 0, "log-format", "Log using `FORMAT` (text or json).")
+	fset.StringVar(&logLevelFlag, 0, "log-level", "Log messages at `LEVEL` or above (debug, info, warn, or error).")
+	fset.StringVar(&sizeFlag, 's', "size", "Transfer `SIZE` bytes per request (default: @DEFAULT_VALUE@).")
+	globalConfig.apply(fset)
+	runtimex.PanicOnError0(fset.Parse(args))
+	runtimex.LogFatalOnError0(slogging.Setup(logFormatFlag, logLevelFlag))
+	size := runtimex.LogFatalOnError1(transfer.ParseSize(sizeFlag))
+
+	// We use the same handlers as http1-server on a TLS listener
+	// bound to an ephemeral port on 127.0.0.1. Handlers record GET
+	// transfers after the client may have already read the whole body,
+	// so we signal when they have returned.
+	h := &transfer.Handler{Summary: transfer.NewSummary(16)}
+	completed := make(chan struct{}, 1)
+	track := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(rw http.ResponseWriter, req *http.Request) {
+			next(rw, req)
+			select {
+			case completed <- struct{}{}:
+			default:
+			}
+		}
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/{size}", track(h.HandleGet))
+	mux.Handle("PUT /api/{size}", track(h.HandlePut))
+	mux.Handle("GET /summary", http.HandlerFunc(h.Summary.HandleSummary))
+	srv := httptest.NewTLSServer(mux)
+	defer srv.Close()
+	client := srv.Client()
+	URL := fmt.Sprintf("%s/api/%d", srv.URL, size)
+
+	passed := true
+	for _, method := range []string{http.MethodGet, http.MethodPut} {
+		err := selftestCheck(ctx, client, completed, srv.URL, method, URL, size)
+		if err != nil {
+			passed = false
+			fmt.Fprintf(os.Stdout, "%-3s %d bytes: FAIL: %s\n", method, size, err.Error())
+			continue
+		}
+		fmt.Fprintf(os.Stdout, "%-3s %d bytes: PASS\n", method, size)
+	}
+	if !passed {
+		return errSelftestFailed
+	}
+	return nil
+}
+
+// selftestCheck performs a single request and verifies that both the
+// client and the server transferred exactly size bytes.
+func selftestCheck(ctx context.Context, client *http.Client, completed <-chan struct{},
+	baseURL, method, URL string, size int64) error {
+	count := &atomic.Int64{}
+	if err := clientRequest(ctx, client, method, URL, size, count); err != nil {
+		return err
+	}
+	if got := count.Load(); got != size {
+		return fmt.Errorf("client transferred %d bytes, expected %d", got, size)
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-completed:
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/summary", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var entries []transfer.SummaryEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return err
+	}
+	if len(entries) <= 0 {
+		return errors.New("server did not record the transfer")
+	}
+	last := entries[len(entries)-1]
+	if last.Method != method || last.Bytes != size {
+		return fmt.Errorf("server recorded %s of %d bytes, expected %s of %d",
+			last.Method, last.Bytes, method, size)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/bassosimone/2026-02-js-perf/internal/buildinfo"
+	"github.com/bassosimone/runtimex"
+	"github.com/bassosimone/vflag"
+)
+
+func versionMain(ctx context.Context, args []string) error {
+	fset := vflag.NewFlagSet("lxs version", vflag.ExitOnError)
+	fset.AutoHelp('h', "help", "Print this help text and exit.")
+	globalConfig.apply(fset)
+	runtimex.PanicOnError0(fset.Parse(args))
+
+	info := buildinfo.Read()
+	fmt.Fprintf(os.Stdout, "module:     %s\n", info.Module)
+	fmt.Fprintf(os.Stdout, "version:    %s\n", info.Version)
+	fmt.Fprintf(os.Stdout, "commit:     %s\n", info.Commit)
+	fmt.Fprintf(os.Stdout, "commitTime: %s\n", info.CommitTime)
+	fmt.Fprintf(os.Stdout, "modified:   %t\n", info.Modified)
+	fmt.Fprintf(os.Stdout, "goVersion:  %s\n", info.GoVersion)
+	return nil
+}
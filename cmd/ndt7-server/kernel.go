@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+// tcpInfo is the ndt7 TCPInfo measurement, which mirrors the Linux
+// kernel struct tcp_info (times are in microseconds).
+type tcpInfo struct {
+	State         uint8
+	CAState       uint8
+	Retransmits   uint8
+	Probes        uint8
+	Backoff       uint8
+	Options       uint8
+	RTO           uint32
+	ATO           uint32
+	SndMSS        uint32
+	RcvMSS        uint32
+	Unacked       uint32
+	Sacked        uint32
+	Lost          uint32
+	Retrans       uint32
+	Fackets       uint32
+	LastDataSent  uint32
+	LastAckSent   uint32
+	LastDataRecv  uint32
+	LastAckRecv   uint32
+	PMTU          uint32
+	RcvSsThresh   uint32
+	RTT           uint32
+	RTTVar        uint32
+	SndSsThresh   uint32
+	SndCwnd       uint32
+	AdvMSS        uint32
+	Reordering    uint32
+	RcvRTT        uint32
+	RcvSpace      uint32
+	TotalRetrans  uint32
+	PacingRate    int64
+	MaxPacingRate int64
+	BytesAcked    int64
+	BytesReceived int64
+	SegsOut       int32
+	SegsIn        int32
+	NotsentBytes  uint32
+	MinRTT        uint32
+	DataSegsIn    uint32
+	DataSegsOut   uint32
+	DeliveryRate  int64
+	BusyTime      int64
+	RWndLimited   int64
+	SndBufLimited int64
+	Delivered     uint32
+	DeliveredCE   uint32
+	BytesSent     int64
+	BytesRetrans  int64
+	DSackDups     uint32
+	ReordSeen     uint32
+	RcvOooPack    uint32
+	SndWnd        uint32
+
+	// ElapsedTime is the time since the beginning of the test in microseconds.
+	ElapsedTime int64
+}
+
+// bbrInfo is the ndt7 BBRInfo measurement.
+type bbrInfo struct {
+	// BW is the max-filtered bandwidth estimate in bytes per second.
+	BW int64
+
+	// MinRTT is the min-filtered RTT in microseconds.
+	MinRTT int64
+
+	// PacingGain is the pacing gain shifted left 8 bits.
+	PacingGain int64
+
+	// CwndGain is the cwnd gain shifted left 8 bits.
+	CwndGain int64
+
+	// ElapsedTime is the time since the beginning of the test in microseconds.
+	ElapsedTime int64
+}
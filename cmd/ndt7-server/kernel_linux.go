@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+//go:build linux
+
+package main
+
+import (
+	"net"
+	"time"
+
+	"github.com/bassosimone/2026-02-js-perf/internal/sockopt"
+	"golang.org/x/sys/unix"
+)
+
+// readKernelInfo returns the TCP_INFO and, when using BBR, the BBR
+// statistics of conn, or nil for the information it cannot read.
+func readKernelInfo(conn net.Conn, elapsed time.Duration) (*tcpInfo, *bbrInfo) {
+	rawConn, err := sockopt.SyscallConn(conn)
+	if err != nil {
+		return nil, nil
+	}
+	algorithm, _ := sockopt.GetCongestion(rawConn)
+	var (
+		info    *unix.TCPInfo
+		infoErr error
+		bbr     *unix.TCPBBRInfo
+		bbrErr  error = unix.ENOPROTOOPT
+	)
+	err = rawConn.Control(func(fd uintptr) {
+		info, infoErr = unix.GetsockoptTCPInfo(int(fd), unix.IPPROTO_TCP, unix.TCP_INFO)
+		if algorithm == "bbr" {
+			bbr, bbrErr = unix.GetsockoptTCPCCBBRInfo(int(fd), unix.IPPROTO_TCP, unix.TCP_CC_INFO)
+		}
+	})
+	if err != nil {
+		return nil, nil
+	}
+	var (
+		ti *tcpInfo
+		bi *bbrInfo
+	)
+	if infoErr == nil {
+		ti = newTCPInfo(info, elapsed)
+	}
+	if bbrErr == nil {
+		bi = &bbrInfo{
+			BW:          int64(bbr.Bw_hi)<<32 | int64(bbr.Bw_lo),
+			MinRTT:      int64(bbr.Min_rtt),
+			PacingGain:  int64(bbr.Pacing_gain),
+			CwndGain:    int64(bbr.Cwnd_gain),
+			ElapsedTime: elapsed.Microseconds(),
+		}
+	}
+	return ti, bi
+}
+
+// newTCPInfo converts the kernel TCP_INFO to the ndt7 format.
+func newTCPInfo(info *unix.TCPInfo, elapsed time.Duration) *tcpInfo {
+	return &tcpInfo{
+		State:         info.State,
+		CAState:       info.Ca_state,
+		Retransmits:   info.Retransmits,
+		Probes:        info.Probes,
+		Backoff:       info.Backoff,
+		Options:       info.Options,
+		RTO:           info.Rto,
+		ATO:           info.Ato,
+		SndMSS:        info.Snd_mss,
+		RcvMSS:        info.Rcv_mss,
+		Unacked:       info.Unacked,
+		Sacked:        info.Sacked,
+		Lost:          info.Lost,
+		Retrans:       info.Retrans,
+		Fackets:       info.Fackets,
+		LastDataSent:  info.Last_data_sent,
+		LastAckSent:   info.Last_ack_sent,
+		LastDataRecv:  info.Last_data_recv,
+		LastAckRecv:   info.Last_ack_recv,
+		PMTU:          info.Pmtu,
+		RcvSsThresh:   info.Rcv_ssthresh,
+		RTT:           info.Rtt,
+		RTTVar:        info.Rttvar,
+		SndSsThresh:   info.Snd_ssthresh,
+		SndCwnd:       info.Snd_cwnd,
+		AdvMSS:        info.Advmss,
+		Reordering:    info.Reordering,
+		RcvRTT:        info.Rcv_rtt,
+		RcvSpace:      info.Rcv_space,
+		TotalRetrans:  info.Total_retrans,
+		PacingRate:    int64(info.Pacing_rate),
+		MaxPacingRate: int64(info.Max_pacing_rate),
+		BytesAcked:    int64(info.Bytes_acked),
+		BytesReceived: int64(info.Bytes_received),
+		SegsOut:       int32(info.Segs_out),
+		SegsIn:        int32(info.Segs_in),
+		NotsentBytes:  info.Notsent_bytes,
+		MinRTT:        info.Min_rtt,
+		DataSegsIn:    info.Data_segs_in,
+		DataSegsOut:   info.Data_segs_out,
+		DeliveryRate:  int64(info.Delivery_rate),
+		BusyTime:      int64(info.Busy_time),
+		RWndLimited:   int64(info.Rwnd_limited),
+		SndBufLimited: int64(info.Sndbuf_limited),
+		Delivered:     info.Delivered,
+		DeliveredCE:   info.Delivered_ce,
+		BytesSent:     int64(info.Bytes_sent),
+		BytesRetrans:  int64(info.Bytes_retrans),
+		DSackDups:     info.Dsack_dups,
+		ReordSeen:     info.Reord_seen,
+		RcvOooPack:    info.Rcv_ooopack,
+		SndWnd:        info.Snd_wnd,
+		ElapsedTime:   elapsed.Microseconds(),
+	}
+}
@@ -0,0 +1,15 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+//go:build !linux
+
+package main
+
+import (
+	"net"
+	"time"
+)
+
+// readKernelInfo returns nil because we cannot read kernel statistics.
+func readKernelInfo(conn net.Conn, elapsed time.Duration) (*tcpInfo, *bbrInfo) {
+	return nil, nil
+}
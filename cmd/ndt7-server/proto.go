@@ -8,10 +8,12 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"os"
 	"time"
 
 	"github.com/bassosimone/2026-02-js-perf/internal/humanize"
 	"github.com/gorilla/websocket"
+	"golang.org/x/time/rate"
 
 	"net/http"
 )
@@ -20,14 +22,14 @@ const (
 	// minMessageSize is the initial WebSocket message size.
 	minMessageSize = 1 << 10
 
-	// maxScaledMessageSize is the maximum message size during scaling.
-	maxScaledMessageSize = 1 << 20
+	// defaultMaxScaledMessageSize is the default maximum message size during scaling.
+	defaultMaxScaledMessageSize = 1 << 20
 
 	// maxMessageSize is the maximum accepted message size.
 	maxMessageSize = 1 << 24
 
-	// maxRuntime is the maximum duration for a test.
-	maxRuntime = 10 * time.Second
+	// defaultMaxRuntime is the default maximum duration for a test.
+	defaultMaxRuntime = 10 * time.Second
 
 	// measureInterval is the interval between measurement reports.
 	measureInterval = 250 * time.Millisecond
@@ -39,19 +41,58 @@ const (
 	wsProto = "net.measurementlab.ndt.v7"
 )
 
-// emitAppInfo logs a local measurement using slog.
-func emitAppInfo(start time.Time, total int64, testname string) {
-	elapsed := time.Since(start).Seconds()
+// testConfig contains the tunable test parameters.
+type testConfig struct {
+	// maxRuntime is the maximum duration for a test.
+	maxRuntime time.Duration
+
+	// maxScaledMessageSize is the maximum message size during scaling.
+	maxScaledMessageSize int
+}
+
+// appInfo contains application-level measurements.
+type appInfo struct {
+	// ElapsedTime is the time since the beginning of the test in microseconds.
+	ElapsedTime int64
+
+	// NumBytes is the number of bytes sent or received at application level.
+	NumBytes int64
+}
+
+// measurement is the ndt7 Measurement message.
+type measurement struct {
+	AppInfo *appInfo `json:",omitempty"`
+	BBRInfo *bbrInfo `json:",omitempty"`
+	Origin  string   `json:",omitempty"`
+	TCPInfo *tcpInfo `json:",omitempty"`
+	Test    string   `json:",omitempty"`
+}
+
+// emitMeasurement logs a measurement using slog and sends it to the peer
+// as a JSON text message, including kernel statistics where available.
+func emitMeasurement(conn *websocket.Conn, start time.Time, total int64, testname string) error {
+	elapsed := time.Since(start)
 	var speed float64
 	if elapsed > 0 {
-		speed = float64(total) * 8 / elapsed
+		speed = float64(total) * 8 / elapsed.Seconds()
 	}
 	slog.Info(testname,
 		slog.String("test", testname),
 		slog.String("bytes", humanize.IEC(float64(total), "B")),
-		slog.String("elapsed", time.Since(start).Truncate(time.Millisecond).String()),
+		slog.String("elapsed", elapsed.Truncate(time.Millisecond).String()),
 		slog.String("speed", humanize.SI(speed, "bit/s")),
 	)
+	tcpInfo, bbrInfo := readKernelInfo(conn.NetConn(), elapsed)
+	return conn.WriteJSON(&measurement{
+		AppInfo: &appInfo{
+			ElapsedTime: elapsed.Microseconds(),
+			NumBytes:    total,
+		},
+		BBRInfo: bbrInfo,
+		Origin:  "server",
+		TCPInfo: tcpInfo,
+		Test:    testname,
+	})
 }
 
 // newMessage creates a prepared WebSocket binary message of the given size.
@@ -59,35 +100,65 @@ func newMessage(n int) (*websocket.PreparedMessage, error) {
 	return websocket.NewPreparedMessage(websocket.BinaryMessage, make([]byte, n))
 }
 
-// sender writes binary WebSocket messages with adaptive sizing. Used by
-// the server for download and by the client for upload.
-func sender(ctx context.Context, conn *websocket.Conn, testname string) error {
+// sender writes binary WebSocket messages with adaptive sizing and
+// periodic measurements. Used by the server for download.
+//
+// When limiter is not nil, we pace the messages using it and do not
+// scale them beyond its burst size.
+func sender(ctx context.Context, conn *websocket.Conn, testname string, config *testConfig, limiter *rate.Limiter) error {
+	// We must read to process control frames (e.g., close), and the
+	// client may also send its own measurements, which we ignore.
+	conn.SetReadLimit(maxMessageSize)
+	go func() {
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
 	var total int64
 	start := time.Now()
-	if err := conn.SetWriteDeadline(start.Add(maxRuntime)); err != nil {
+	if err := conn.SetWriteDeadline(start.Add(config.maxRuntime)); err != nil {
 		return err
 	}
-	size := minMessageSize
+	size, maxSize := minMessageSize, config.maxScaledMessageSize
+	if limiter != nil {
+		maxSize = min(maxSize, limiter.Burst())
+	}
 	message, err := newMessage(size)
 	if err != nil {
 		return err
 	}
 	ticker := time.NewTicker(measureInterval)
 	defer ticker.Stop()
-	for ctx.Err() == nil {
-		if err := conn.WritePreparedMessage(message); err != nil {
+	waitCtx, cancel := context.WithDeadline(ctx, start.Add(config.maxRuntime))
+	defer cancel()
+	for ctx.Err() == nil && time.Since(start) < config.maxRuntime {
+		if limiter != nil {
+			if err := limiter.WaitN(waitCtx, size); err != nil {
+				return nil // the next message would exceed maxRuntime
+			}
+		}
+		err := conn.WritePreparedMessage(message)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return nil // the test ran for maxRuntime
+		}
+		if err != nil {
 			return err
 		}
 		total += int64(size)
 		select {
 		case <-ticker.C:
-			emitAppInfo(start, total, testname)
+			if err := emitMeasurement(conn, start, total, testname); err != nil {
+				return err
+			}
 		default:
 		}
-		if int64(size) >= maxScaledMessageSize || int64(size) >= (total/fractionForScaling) {
+		if size >= maxSize || int64(size) >= (total/fractionForScaling) {
 			continue
 		}
-		size <<= 1
+		size = min(size<<1, maxSize)
 		if message, err = newMessage(size); err != nil {
 			return err
 		}
@@ -95,20 +166,23 @@ func sender(ctx context.Context, conn *websocket.Conn, testname string) error {
 	return nil
 }
 
-// receiver reads WebSocket messages and discards binary data.
-// Text messages (server-side measurements) are printed to stdout.
-// Used by the client for download and by the server for upload.
-func receiver(ctx context.Context, conn *websocket.Conn, testname string) error {
+// receiver reads WebSocket messages, discards binary data, and sends
+// periodic measurements. Text messages (client-side measurements) are
+// printed to stdout. Used by the server for upload.
+func receiver(ctx context.Context, conn *websocket.Conn, testname string, config *testConfig) error {
 	var total int64
 	start := time.Now()
-	if err := conn.SetReadDeadline(start.Add(maxRuntime)); err != nil {
+	if err := conn.SetReadDeadline(start.Add(config.maxRuntime)); err != nil {
 		return err
 	}
 	conn.SetReadLimit(maxMessageSize)
 	ticker := time.NewTicker(measureInterval)
 	defer ticker.Stop()
-	for ctx.Err() == nil {
+	for ctx.Err() == nil && time.Since(start) < config.maxRuntime {
 		kind, reader, err := conn.NextReader()
+		if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+			return nil
+		}
 		if err != nil {
 			return err
 		}
@@ -128,13 +202,22 @@ func receiver(ctx context.Context, conn *websocket.Conn, testname string) error
 		total += n
 		select {
 		case <-ticker.C:
-			emitAppInfo(start, total, testname)
+			if err := emitMeasurement(conn, start, total, testname); err != nil {
+				return err
+			}
 		default:
 		}
 	}
 	return nil
 }
 
+// closeGracefully sends a normal closure message and closes the connection.
+func closeGracefully(conn *websocket.Conn) {
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+	conn.Close()
+}
+
 // upgrade performs the WebSocket upgrade handshake on the server side.
 func upgrade(rw http.ResponseWriter, req *http.Request) (*websocket.Conn, error) {
 	if req.Header.Get("Sec-WebSocket-Protocol") != wsProto {
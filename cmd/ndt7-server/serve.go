@@ -4,32 +4,81 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"log"
 	"log/slog"
 	"net"
 	"net/http"
 
+	"github.com/bassosimone/2026-02-js-perf/internal/certreload"
+	"github.com/bassosimone/2026-02-js-perf/internal/cpuaffinity"
+	"github.com/bassosimone/2026-02-js-perf/internal/envflag"
+	"github.com/bassosimone/2026-02-js-perf/internal/humanize"
+	"github.com/bassosimone/2026-02-js-perf/internal/keylog"
+	"github.com/bassosimone/2026-02-js-perf/internal/slogging"
+	"github.com/bassosimone/2026-02-js-perf/internal/transfer"
 	"github.com/bassosimone/runtimex"
 	"github.com/bassosimone/vflag"
+	"golang.org/x/time/rate"
 )
 
 func serveMain(ctx context.Context, args []string) error {
 	var (
-		addressFlag   = "127.0.0.1"
-		certFlag      = "testdata/cert.pem"
-		keyFlag       = "testdata/key.pem"
-		portFlag      = "4567"
-		staticDirFlag = "./static/ndt7"
+		addressFlag         = "127.0.0.1"
+		certFlag            = "testdata/cert.pem"
+		cpuAffinityFlag     = ""
+		gomaxprocsFlag      = 0
+		keyFlag             = "testdata/key.pem"
+		keylogFlag          = ""
+		logFormatFlag       = "text"
+		logLevelFlag        = "info"
+		ndtDownloadRateFlag = ""
+		ndtDurationFlag     = defaultMaxRuntime
+		ndtMaxMessageFlag   = "1MiB"
+		ocspStapleFlag      = ""
+		portFlag            = "4567"
+		staticDirFlag       = "./static/ndt7"
 	)
 
 	fset := vflag.NewFlagSet("ndt7 serve", vflag.ExitOnError)
 	fset.StringVar(&addressFlag, 'A', "address", "Use the given IP `ADDRESS`.")
 	fset.StringVar(&certFlag, 0, "cert", "Use `FILE` as the TLS certificate.")
+	fset.StringVar(&cpuAffinityFlag, 0, "cpu-affinity", "Pin the process to the CPUs in `LIST` (e.g., 0-3,6; Linux only).")
+	fset.IntVar(&gomaxprocsFlag, 0, "gomaxprocs", "Set GOMAXPROCS to `N` (default: the number of usable CPUs).")
 	fset.AutoHelp('h', "help", "Print this help text and exit.")
 	fset.StringVar(&keyFlag, 0, "key", "Use `FILE` as the TLS private key.")
+	fset.StringVar(&keylogFlag, 0, "keylog", "Append TLS secrets to `FILE` for decrypting packet captures (use \"env\" for $SSLKEYLOGFILE).")
+	fset.StringVar(&logFormatFlag, 0, "log-format", "Log using `FORMAT` (text or json).")
+	fset.StringVar(&logLevelFlag, 0, "log-level", "Log messages at `LEVEL` or above (debug, info, warn, or error).")
+	fset.StringVar(&ndtDownloadRateFlag, 0, "ndt-download-rate", "Pace each download at `RATE` (e.g., 10Mbit or 2MB/s; default: unlimited).")
+	fset.DurationVar(&ndtDurationFlag, 0, "ndt-duration", "Run each download and upload for `DURATION` (default: @DEFAULT_VALUE@).")
+	fset.StringVar(&ndtMaxMessageFlag, 0, "ndt-max-message-size", "Grow download messages up to `SIZE` (default: @DEFAULT_VALUE@).")
+	fset.StringVar(&ocspStapleFlag, 0, "ocsp-staple", "Staple the DER-encoded OCSP response in `FILE` to TLS handshakes (reloaded on SIGHUP).")
 	fset.StringVar(&portFlag, 'p', "port", "Use the given TCP `PORT`.")
 	fset.StringVar(&staticDirFlag, 0, "static-dir", "Serve static files from `DIR`.")
-	runtimex.PanicOnError0(fset.Parse(args))
+	runtimex.LogFatalOnError0(envflag.Parse(fset, args))
+	runtimex.LogFatalOnError0(slogging.Setup(logFormatFlag, logLevelFlag))
+	runtimex.LogFatalOnError0(cpuaffinity.Setup(gomaxprocsFlag, cpuAffinityFlag))
+
+	if ndtDurationFlag <= 0 {
+		log.Fatalf("ndt7-server: invalid duration: %s", ndtDurationFlag)
+	}
+	messageSize := runtimex.LogFatalOnError1(transfer.ParseSize(ndtMaxMessageFlag))
+	if messageSize < minMessageSize || messageSize > maxMessageSize {
+		log.Fatalf("ndt7-server: message size must be within [%d, %d]: %s", minMessageSize, maxMessageSize, ndtMaxMessageFlag)
+	}
+	config := &testConfig{maxRuntime: ndtDurationFlag, maxScaledMessageSize: int(messageSize)}
+	slog.Info("test config",
+		slog.Duration("duration", config.maxRuntime),
+		slog.String("maxMessageSize", humanize.IEC(float64(config.maxScaledMessageSize), "B")),
+	)
+
+	var downloadRate float64
+	if ndtDownloadRateFlag != "" {
+		downloadRate = runtimex.LogFatalOnError1(transfer.ParseRate(ndtDownloadRateFlag))
+		slog.Info("download rate", slog.String("rate", humanize.SI(downloadRate*8, "bit/s")))
+	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/ndt/v7/download", func(rw http.ResponseWriter, req *http.Request) {
@@ -37,28 +86,54 @@ func serveMain(ctx context.Context, args []string) error {
 		if err != nil {
 			return
 		}
+		defer closeGracefully(conn)
 		slog.Info("download", slog.String("remote", req.RemoteAddr))
-		sender(req.Context(), conn, "download")
+		// Each download gets its own limiter, like --egress-rate does
+		// for each http1-server connection.
+		var limiter *rate.Limiter
+		if downloadRate > 0 {
+			limiter = transfer.NewLimiter(downloadRate)
+		}
+		if err := sender(req.Context(), conn, "download", config, limiter); err != nil {
+			slog.Info("download failed", slog.Any("err", err), slog.String("remote", req.RemoteAddr))
+		}
 	})
 	mux.HandleFunc("/ndt/v7/upload", func(rw http.ResponseWriter, req *http.Request) {
 		conn, err := upgrade(rw, req)
 		if err != nil {
 			return
 		}
+		defer closeGracefully(conn)
 		slog.Info("upload", slog.String("remote", req.RemoteAddr))
-		receiver(req.Context(), conn, "upload")
+		if err := receiver(req.Context(), conn, "upload", config); err != nil {
+			slog.Info("upload failed", slog.Any("err", err), slog.String("remote", req.RemoteAddr))
+		}
 	})
 	mux.Handle("/", http.FileServer(http.Dir(staticDirFlag)))
 
+	certs := runtimex.LogFatalOnError1(certreload.New(certFlag, keyFlag, ocspStapleFlag))
+	go certs.WatchSIGHUP(ctx)
+
+	tlsConfig := &tls.Config{GetCertificate: certs.GetCertificate}
+	if keylogPath := keylog.Path(keylogFlag); keylogPath != "" {
+		keylogFile := runtimex.LogFatalOnError1(keylog.Open(keylogPath))
+		defer keylogFile.Close()
+		tlsConfig.KeyLogWriter = keylogFile
+	}
+
 	endpoint := net.JoinHostPort(addressFlag, portFlag)
-	srv := &http.Server{Addr: endpoint, Handler: mux}
+	srv := &http.Server{
+		Addr:      endpoint,
+		Handler:   mux,
+		TLSConfig: tlsConfig,
+	}
 	go func() {
 		defer srv.Close()
 		<-ctx.Done()
 	}()
 
 	slog.Info("serving at", slog.String("addr", endpoint))
-	err := srv.ListenAndServeTLS(certFlag, keyFlag)
+	err := srv.ListenAndServeTLS("", "")
 	slog.Info("interrupted", slog.Any("err", err))
 
 	if errors.Is(err, http.ErrServerClosed) {
@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package buildinfo reports the build information embedded by the Go toolchain.
+package buildinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+)
+
+// Info contains the build information.
+//
+// The Go toolchain does not record the build time, so we report the
+// time of the commit we built from, which is only available when
+// building from a git checkout (e.g., not with `go run`).
+type Info struct {
+	Module     string `json:"module"`
+	Version    string `json:"version"`
+	Commit     string `json:"commit"`
+	CommitTime string `json:"commitTime"`
+	Modified   bool   `json:"modified"`
+	GoVersion  string `json:"goVersion"`
+}
+
+// Read returns the build information of the running binary.
+func Read() Info {
+	info := Info{GoVersion: runtime.Version()}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.Module = bi.Main.Path
+	info.Version = bi.Main.Version
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Commit = setting.Value
+		case "vcs.time":
+			info.CommitTime = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
+
+// HandleVersion handles GET /version by writing the build information as JSON.
+func HandleVersion(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(rw).Encode(Read())
+}
@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package certreload reloads the TLS certificate on SIGHUP.
+package certreload
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+)
+
+// Reloader serves a TLS certificate that can be replaced at runtime
+// without interrupting the existing connections.
+//
+// Construct using [New].
+type Reloader struct {
+	cert     atomic.Pointer[tls.Certificate]
+	certFile string
+	keyFile  string
+	ocspFile string
+}
+
+// New returns a [*Reloader] that loads the certificate from certFile
+// and the private key from keyFile, failing if they cannot be loaded.
+//
+// Unless ocspFile is empty, we also load the DER-encoded OCSP response
+// to staple to handshakes of clients requesting the certificate status.
+func New(certFile, keyFile, ocspFile string) (*Reloader, error) {
+	rl := &Reloader{certFile: certFile, keyFile: keyFile, ocspFile: ocspFile}
+	if err := rl.Reload(); err != nil {
+		return nil, err
+	}
+	return rl, nil
+}
+
+// Reload re-reads the certificate, the key, and the OCSP response, if
+// any, and swaps them in, such that SIGHUP also refreshes the staple
+// before it expires. On failure, we keep serving the previous certificate.
+func (rl *Reloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(rl.certFile, rl.keyFile)
+	if err != nil {
+		return err
+	}
+	if rl.ocspFile != "" {
+		if cert.OCSPStaple, err = os.ReadFile(rl.ocspFile); err != nil {
+			return err
+		}
+	}
+	rl.cert.Store(&cert)
+	return nil
+}
+
+// GetCertificate implements [tls.Config.GetCertificate].
+func (rl *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return rl.cert.Load(), nil
+}
+
+// WatchSIGHUP reloads the certificate on each SIGHUP until ctx is done,
+// logging the outcome. Run it in a background goroutine.
+func (rl *Reloader) WatchSIGHUP(ctx context.Context) {
+	sigch := make(chan os.Signal, 1)
+	signal.Notify(sigch, syscall.SIGHUP)
+	defer signal.Stop(sigch)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigch:
+			if err := rl.Reload(); err != nil {
+				slog.Warn("cert reload failed", slog.String("cert", rl.certFile), slog.Any("err", err))
+				continue
+			}
+			slog.Info("cert reloaded", slog.String("cert", rl.certFile))
+		}
+	}
+}
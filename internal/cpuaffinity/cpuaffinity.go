@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package cpuaffinity pins the process to a CPU set and tunes GOMAXPROCS,
+// such that throughput benchmarks are reproducible.
+package cpuaffinity
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Parse parses a CPU list in the taskset(1) format (e.g., 0-3,6).
+func Parse(spec string) ([]int, error) {
+	var cpus []int
+	seen := map[int]bool{}
+	for entry := range strings.SplitSeq(spec, ",") {
+		first, last, isRange := strings.Cut(entry, "-")
+		lo, err := strconv.Atoi(first)
+		if err != nil || lo < 0 {
+			return nil, fmt.Errorf("invalid CPU list: %q", spec)
+		}
+		hi := lo
+		if isRange {
+			hi, err = strconv.Atoi(last)
+			if err != nil || hi < lo {
+				return nil, fmt.Errorf("invalid CPU list: %q", spec)
+			}
+		}
+		for cpu := lo; cpu <= hi; cpu++ {
+			if !seen[cpu] {
+				seen[cpu] = true
+				cpus = append(cpus, cpu)
+			}
+		}
+	}
+	return cpus, nil
+}
+
+// Setup pins the process to the CPUs in spec (Linux only), unless
+// empty, and sets GOMAXPROCS to gomaxprocs, unless zero, and logs the
+// effective settings.
+//
+// When pinning without gomaxprocs, GOMAXPROCS becomes the number of
+// CPUs in spec, as if the process started with that affinity.
+func Setup(gomaxprocs int, spec string) error {
+	if gomaxprocs < 0 {
+		return fmt.Errorf("invalid GOMAXPROCS: %d", gomaxprocs)
+	}
+	if spec != "" {
+		cpus, err := Parse(spec)
+		if err != nil {
+			return err
+		}
+		if err := pin(cpus); err != nil {
+			return err
+		}
+		runtime.SetDefaultGOMAXPROCS()
+	}
+	if gomaxprocs > 0 {
+		runtime.GOMAXPROCS(gomaxprocs)
+	}
+	attrs := []any{
+		slog.Int("gomaxprocs", runtime.GOMAXPROCS(0)),
+		slog.Int("numCPU", runtime.NumCPU()),
+	}
+	if cpus, err := affinity(); err == nil {
+		attrs = append(attrs, slog.Any("cpus", cpus))
+	}
+	slog.Info("cpu settings", attrs...)
+	return nil
+}
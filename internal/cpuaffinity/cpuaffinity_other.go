@@ -0,0 +1,17 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+//go:build !linux
+
+package cpuaffinity
+
+import "errors"
+
+// pin sets the affinity of every thread of the process to cpus.
+func pin(cpus []int) error {
+	return errors.ErrUnsupported
+}
+
+// affinity returns the CPUs the calling thread may run on.
+func affinity() ([]int, error) {
+	return nil, errors.ErrUnsupported
+}
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package envflag provides environment-variable fallbacks for flags.
+package envflag
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bassosimone/vflag"
+)
+
+// Prefix is the prefix of the environment variables we read.
+const Prefix = "LXS_"
+
+// VariableName returns the environment variable for the given flag long
+// name (e.g., LXS_LOG_LEVEL for --log-level).
+func VariableName(name string) string {
+	return Prefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// Parse parses args using fset and then sets each long flag that the
+// command line did not change from the corresponding environment variable,
+// if set, such that flags take precedence over the environment.
+//
+// We consider a flag unset when its value is unchanged by parsing, so
+// explicitly passing the default value also defers to the environment.
+func Parse(fset *vflag.FlagSet, args []string) error {
+	defaults := make([]string, len(fset.LongFlags))
+	for idx, flag := range fset.LongFlags {
+		defaults[idx] = flag.Value.String()
+	}
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	for idx, flag := range fset.LongFlags {
+		if flag.Name == "help" || flag.Value.String() != defaults[idx] {
+			continue
+		}
+		value, found := os.LookupEnv(VariableName(flag.Name))
+		if !found {
+			continue
+		}
+		if err := flag.Value.Set(value); err != nil {
+			return fmt.Errorf("%s: %w", VariableName(flag.Name), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package geoip annotates requests with the coarse client location.
+package geoip
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/netip"
+
+	"github.com/oschwald/maxminddb-golang/v2"
+)
+
+// Location is the coarse location of a client.
+type Location struct {
+	// ASN is the autonomous system number or zero when unknown.
+	ASN uint
+
+	// ASOrg is the autonomous system organization or empty when unknown.
+	ASOrg string
+
+	// Country is the ISO 3166-1 country code or empty when unknown.
+	Country string
+}
+
+// Attr returns the location as an slog group, which is empty, and
+// therefore omitted, when the location is unknown.
+func (loc Location) Attr() slog.Attr {
+	var attrs []any
+	if loc.Country != "" {
+		attrs = append(attrs, slog.String("country", loc.Country))
+	}
+	if loc.ASN != 0 {
+		attrs = append(attrs, slog.Uint64("asn", uint64(loc.ASN)), slog.String("asOrg", loc.ASOrg))
+	}
+	return slog.Group("geo", attrs...)
+}
+
+// record contains the fields we read from MaxMind databases, such that
+// we can use both country and ASN databases.
+type record struct {
+	ASN     uint   `maxminddb:"autonomous_system_number"`
+	ASOrg   string `maxminddb:"autonomous_system_organization"`
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+// DB looks up client locations using MaxMind databases.
+//
+// Construct using [Open]. A nil [*DB] is valid and does not
+// look up anything, which is the case when disabled.
+type DB struct {
+	readers []*maxminddb.Reader
+}
+
+// Open opens the given MaxMind databases (e.g., GeoLite2-Country and
+// GeoLite2-ASN), such that lookups merge their fields.
+func Open(paths ...string) (*DB, error) {
+	db := &DB{}
+	for _, path := range paths {
+		reader, err := maxminddb.Open(path)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		db.readers = append(db.readers, reader)
+	}
+	return db, nil
+}
+
+// Close closes the databases.
+func (db *DB) Close() error {
+	if db == nil {
+		return nil
+	}
+	var errs []error
+	for _, reader := range db.readers {
+		errs = append(errs, reader.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Lookup returns the location of addr, leaving unknown fields empty.
+func (db *DB) Lookup(addr netip.Addr) Location {
+	var loc Location
+	if db == nil {
+		return loc
+	}
+	for _, reader := range db.readers {
+		var rec record
+		if err := reader.Lookup(addr.Unmap()).Decode(&rec); err != nil {
+			continue
+		}
+		loc.ASN = max(loc.ASN, rec.ASN)
+		if rec.ASOrg != "" {
+			loc.ASOrg = rec.ASOrg
+		}
+		if rec.Country.ISOCode != "" {
+			loc.Country = rec.Country.ISOCode
+		}
+	}
+	return loc
+}
+
+// locationKey is the context key for the [Location].
+type locationKey struct{}
+
+// FromContext returns the [Location] stored into ctx by [DB.Wrap], if any.
+func FromContext(ctx context.Context) Location {
+	loc, _ := ctx.Value(locationKey{}).(Location)
+	return loc
+}
+
+// Wrap returns a handler storing the client location into the request
+// context before calling next. With a nil [*DB], it returns next.
+func (db *DB) Wrap(next http.Handler) http.Handler {
+	if db == nil {
+		return next
+	}
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if addrport, err := netip.ParseAddrPort(req.RemoteAddr); err == nil {
+			ctx := context.WithValue(req.Context(), locationKey{}, db.Lookup(addrport.Addr()))
+			req = req.WithContext(ctx)
+		}
+		next.ServeHTTP(rw, req)
+	})
+}
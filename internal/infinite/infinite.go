@@ -4,13 +4,36 @@ package infinite
 
 import "io"
 
-// Reader is an infinite [io.Reader].
+// zeros is the read-only buffer of zero bytes used by WriteTo.
+var zeros [1 << 20]byte
+
+// Reader is an infinite [io.Reader] returning zero bytes.
+//
+// The zero value is ready to use.
 type Reader struct{}
 
-var _ io.Reader = Reader{}
+var (
+	_ io.Reader   = Reader{}
+	_ io.WriterTo = Reader{}
+)
 
 // Read implements [io.Reader].
 func (r Reader) Read(data []byte) (int, error) {
+	if len(data) <= 0 {
+		return 0, nil
+	}
 	clear(data)
 	return len(data), nil
 }
+
+// WriteTo implements [io.WriterTo].
+func (r Reader) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for {
+		count, err := w.Write(zeros[:])
+		total += int64(count)
+		if err != nil {
+			return total, err
+		}
+	}
+}
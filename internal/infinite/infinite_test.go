@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package infinite
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// readerCases contains the readers under test along with the golden
+// first 16 bytes of their streams.
+var readerCases = []struct {
+	name      string
+	newReader func(offset uint64) io.Reader
+	prefix    []byte
+}{{
+	name:      "zero",
+	newReader: func(uint64) io.Reader { return Reader{} },
+	prefix:    make([]byte, 16),
+}, {
+	name:      "pattern",
+	newReader: func(offset uint64) io.Reader { return NewPatternReader(offset) },
+	prefix:    []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+}, {
+	name:      "random",
+	newReader: func(offset uint64) io.Reader { return NewRandomReaderAt(0, offset) },
+	prefix: []byte{
+		0xaf, 0xcd, 0x1d, 0x7b, 0x39, 0xa8, 0x20, 0xe2,
+		0xf4, 0x65, 0xb9, 0xa1, 0x6a, 0x9e, 0x78, 0x6e,
+	},
+}, {
+	name:      "offset",
+	newReader: func(offset uint64) io.Reader { return NewOffsetReader(offset) },
+	prefix:    []byte{0, 0, 0, 0, 0, 0, 0, 0, 8, 0, 0, 0, 0, 0, 0, 0},
+}}
+
+func TestReaders(t *testing.T) {
+	for _, tc := range readerCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The stream must not depend on how the reads are split, and every
+			// read, including empty and huge ones, must fill data without error.
+			var stream []byte
+			r := tc.newReader(0)
+			for _, size := range []int{0, 1, 0, 7, 9, 255, 1, 1<<20 + 3} {
+				data := bytes.Repeat([]byte{0x55}, size)
+				count, err := r.Read(data)
+				if count != size || err != nil {
+					t.Fatalf("Read(%d): got (%d, %v), want (%d, nil)", size, count, err, size)
+				}
+				stream = append(stream, data...)
+			}
+			if count, err := r.Read(nil); count != 0 || err != nil {
+				t.Fatalf("Read(nil): got (%d, %v), want (0, nil)", count, err)
+			}
+
+			if !bytes.Equal(stream[:len(tc.prefix)], tc.prefix) {
+				t.Fatalf("prefix: got %x, want %x", stream[:len(tc.prefix)], tc.prefix)
+			}
+
+			whole := make([]byte, len(stream))
+			if _, err := tc.newReader(0).Read(whole); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(whole, stream) {
+				t.Fatal("split reads do not match a single read")
+			}
+
+			// Starting at an offset must yield the same bytes as skipping them.
+			for _, offset := range []uint64{0, 1, 7, 8, 9, 255, 256, 257, 1000} {
+				data := make([]byte, 64)
+				if _, err := tc.newReader(offset).Read(data); err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(data, stream[offset:offset+64]) {
+					t.Fatalf("offset %d: got %x, want %x", offset, data, stream[offset:offset+64])
+				}
+			}
+		})
+	}
+}
+
+func TestLimitReader(t *testing.T) {
+	for _, tc := range readerCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stream := make([]byte, len(zeros)+1)
+			if _, err := tc.newReader(0).Read(stream); err != nil {
+				t.Fatal(err)
+			}
+			for _, limit := range []int64{-1, 0, 1, 7, 8, 9, int64(len(zeros)) - 1, int64(len(zeros)), int64(len(zeros)) + 1} {
+				want := stream[:max(limit, 0)]
+
+				// Hiding WriteTo forces io.ReadAll to use Read.
+				got, err := io.ReadAll(struct{ io.Reader }{LimitReader(tc.newReader(0), limit)})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Fatalf("Read with limit %d: got %d bytes, want %d bytes", limit, len(got), len(want))
+				}
+
+				var buf bytes.Buffer
+				count, err := LimitReader(tc.newReader(0), limit).WriteTo(&buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if count != int64(len(want)) || !bytes.Equal(buf.Bytes(), want) {
+					t.Fatalf("WriteTo with limit %d: got %d bytes, want %d bytes", limit, count, len(want))
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRandomReader(b *testing.B) {
+	r := NewRandomReader(0)
+	buf := make([]byte, 1<<16)
+	b.SetBytes(int64(len(buf)))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
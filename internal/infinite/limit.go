@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package infinite
+
+import "io"
+
+// LimitedReader reads at most N bytes from R, implementing [io.WriterTo].
+//
+// Construct using [LimitReader].
+type LimitedReader struct {
+	R io.Reader // underlying reader
+	N int64     // max bytes remaining
+}
+
+// LimitReader returns a [*LimitedReader] reading at most n bytes from r.
+func LimitReader(r io.Reader, n int64) *LimitedReader {
+	return &LimitedReader{R: r, N: n}
+}
+
+var (
+	_ io.Reader   = &LimitedReader{}
+	_ io.WriterTo = &LimitedReader{}
+)
+
+// Read implements [io.Reader].
+func (lr *LimitedReader) Read(data []byte) (int, error) {
+	if lr.N <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(data)) > lr.N {
+		data = data[:lr.N]
+	}
+	count, err := lr.R.Read(data)
+	lr.N -= int64(count)
+	return count, err
+}
+
+// WriteTo implements [io.WriterTo].
+func (lr *LimitedReader) WriteTo(w io.Writer) (int64, error) {
+	_, zero := lr.R.(Reader)
+	buf := zeros[:]
+	if !zero {
+		buf = make([]byte, min(max(lr.N, 0), int64(len(zeros))))
+	}
+	var total int64
+	for lr.N > 0 {
+		chunk := buf[:min(lr.N, int64(len(buf)))]
+		if !zero {
+			if _, err := io.ReadFull(lr.R, chunk); err != nil {
+				return total, err
+			}
+		}
+		count, err := w.Write(chunk)
+		total += int64(count)
+		lr.N -= int64(count)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
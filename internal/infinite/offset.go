@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package infinite
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// OffsetReader is an infinite [io.Reader] where each 8-byte word is its own offset.
+type OffsetReader struct {
+	offset uint64
+}
+
+// NewOffsetReader constructs a new [*OffsetReader] whose first byte is
+// the byte at the given offset of the stream.
+func NewOffsetReader(offset uint64) *OffsetReader {
+	return &OffsetReader{offset: offset}
+}
+
+var _ io.Reader = &OffsetReader{}
+
+// Read implements [io.Reader].
+func (r *OffsetReader) Read(data []byte) (int, error) {
+	total := len(data)
+	var word [8]byte
+
+	// Finish the word that the previous read left partially consumed.
+	if skew := r.offset % 8; skew != 0 && len(data) > 0 {
+		binary.LittleEndian.PutUint64(word[:], r.offset-skew)
+		count := copy(data, word[skew:])
+		r.offset += uint64(count)
+		data = data[count:]
+	}
+
+	for len(data) >= 8 {
+		binary.LittleEndian.PutUint64(data, r.offset)
+		r.offset += 8
+		data = data[8:]
+	}
+
+	if len(data) > 0 {
+		binary.LittleEndian.PutUint64(word[:], r.offset)
+		count := copy(data, word[:])
+		r.offset += uint64(count)
+	}
+	return total, nil
+}
@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package infinite
+
+import "io"
+
+// patternTable contains two consecutive copies of the pattern, such that
+// we can copy up to 256 bytes starting at any offset in a single step.
+var patternTable = func() (table [512]byte) {
+	for idx := range table {
+		table[idx] = byte(idx)
+	}
+	return
+}()
+
+// PatternReader is an infinite [io.Reader] returning the 0x00, ..., 0xff pattern.
+type PatternReader struct {
+	offset byte
+}
+
+// NewPatternReader constructs a new [*PatternReader] whose first byte is
+// the byte at the given offset of the stream.
+func NewPatternReader(offset uint64) *PatternReader {
+	return &PatternReader{offset: byte(offset)}
+}
+
+var _ io.Reader = &PatternReader{}
+
+// Read implements [io.Reader].
+func (r *PatternReader) Read(data []byte) (int, error) {
+	total := len(data)
+	for len(data) > 0 {
+		count := copy(data, patternTable[r.offset:int(r.offset)+256])
+		r.offset += byte(count)
+		data = data[count:]
+	}
+	return total, nil
+}
@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package infinite
+
+import (
+	"encoding/binary"
+	"io"
+	"math/rand/v2"
+)
+
+// RandomReader is an infinite [io.Reader] returning pseudo-random bytes.
+//
+// Each 8-byte word is the little-endian encoding of the next value
+// generated by SplitMix64 starting from the given seed:
+//
+//	state += 0x9e3779b97f4a7c15
+//	z := state
+//	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+//	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+//	return z ^ (z >> 31)
+//
+// where all the arithmetic is modulo 2^64.
+//
+// Construct using [NewRandomReader] or [NewRandomReaderAt].
+type RandomReader struct {
+	// pending contains the unread bytes of the last random value.
+	pending []byte
+
+	// source is the source of pseudo-random values.
+	source rand.Source
+
+	// value is the backing storage for pending.
+	value [8]byte
+}
+
+// NewRandomReader constructs a new [*RandomReader] using the given seed.
+func NewRandomReader(seed uint64) *RandomReader {
+	return &RandomReader{source: &splitMix64{state: seed}}
+}
+
+// NewRandomReaderAt constructs a new [*RandomReader] using the given seed
+// whose first byte is the byte at the given offset of the stream.
+func NewRandomReaderAt(seed, offset uint64) *RandomReader {
+	// The state grows by a constant at each step, so we can jump ahead
+	// by offset/8 values and drop the bytes preceding the offset.
+	r := &RandomReader{source: &splitMix64{state: seed + offset/8*splitMix64Gamma}}
+	if skew := offset % 8; skew != 0 {
+		binary.LittleEndian.PutUint64(r.value[:], r.source.Uint64())
+		r.pending = r.value[skew:]
+	}
+	return r
+}
+
+var _ io.Reader = &RandomReader{}
+
+// Read implements [io.Reader].
+func (r *RandomReader) Read(data []byte) (int, error) {
+	total := len(data)
+
+	// Consume the leftover bytes from the previous value first.
+	count := copy(data, r.pending)
+	r.pending, data = r.pending[count:], data[count:]
+
+	for len(data) >= 8 {
+		binary.LittleEndian.PutUint64(data, r.source.Uint64())
+		data = data[8:]
+	}
+
+	if len(data) > 0 {
+		binary.LittleEndian.PutUint64(r.value[:], r.source.Uint64())
+		count := copy(data, r.value[:])
+		r.pending = r.value[count:]
+	}
+	return total, nil
+}
+
+// splitMix64Gamma is the SplitMix64 state increment.
+const splitMix64Gamma = 0x9e3779b97f4a7c15
+
+// splitMix64 is the SplitMix64 [rand.Source].
+type splitMix64 struct {
+	state uint64
+}
+
+var _ rand.Source = &splitMix64{}
+
+// Uint64 implements [rand.Source].
+func (s *splitMix64) Uint64() uint64 {
+	s.state += splitMix64Gamma
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
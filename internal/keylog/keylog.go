@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package keylog writes TLS secrets for decrypting packet captures.
+package keylog
+
+import (
+	"log/slog"
+	"os"
+)
+
+// EnvVar is the conventional environment variable naming the key log file.
+const EnvVar = "SSLKEYLOGFILE"
+
+// FromEnv is the --keylog value selecting the file named by [EnvVar].
+const FromEnv = "env"
+
+// Path returns the key log file for the given --keylog value, which is
+// empty when key logging is disabled.
+//
+// We only honor [EnvVar] when explicitly asked to with [FromEnv], so a
+// stray environment variable cannot silently leak the TLS secrets.
+func Path(value string) string {
+	if value == FromEnv {
+		return os.Getenv(EnvVar)
+	}
+	return value
+}
+
+// Open opens path for appending TLS secrets in the NSS key log format,
+// suitable for [tls.Config.KeyLogWriter], and warns that anyone with
+// access to the file can decrypt the captured traffic.
+func Open(path string) (*os.File, error) {
+	filep, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	slog.Warn("logging TLS secrets; debug use only", slog.String("keylog", path))
+	return filep, nil
+}
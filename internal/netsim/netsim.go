@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package netsim simulates impaired links at the application layer.
+//
+// Delaying, dropping, or duplicating whole writes on top of TCP is only
+// a coarse approximation of a lossy path: TCP never sees the losses and
+// does not retransmit, so a dropped or duplicated write corrupts the
+// stream (TLS notices and closes the connection). It is still useful to
+// smoke test how clients react to slow, stalling, and failing transfers.
+package netsim
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net"
+	"syscall"
+	"time"
+)
+
+// Config configures the impairments.
+type Config struct {
+	// Delay is the delay before each write.
+	Delay time.Duration
+
+	// Duplicate is the probability of writing the same data twice.
+	Duplicate float64
+
+	// Loss is the probability of dropping a write.
+	Loss float64
+}
+
+// errInvalidProbability indicates that a probability is not within [0, 1].
+var errInvalidProbability = errors.New("netsim: probability must be within [0, 1]")
+
+// Validate returns an error if the configuration is invalid.
+func (c *Config) Validate() error {
+	if c.Duplicate < 0 || c.Duplicate > 1 || c.Loss < 0 || c.Loss > 1 {
+		return errInvalidProbability
+	}
+	return nil
+}
+
+// Enabled returns whether the configuration impairs connections at all.
+func (c *Config) Enabled() bool {
+	return c.Delay > 0 || c.Duplicate > 0 || c.Loss > 0
+}
+
+// Listener is a [net.Listener] returning impaired connections.
+//
+// Construct using [NewListener].
+type Listener struct {
+	config Config
+	net.Listener
+}
+
+// NewListener returns a [*Listener] wrapping ln.
+func NewListener(ln net.Listener, config *Config) *Listener {
+	return &Listener{config: *config, Listener: ln}
+}
+
+// Accept implements [net.Listener].
+func (ln *Listener) Accept() (net.Conn, error) {
+	conn, err := ln.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{config: ln.config, Conn: conn}, nil
+}
+
+// Conn is an impaired [net.Conn].
+type Conn struct {
+	config Config
+	net.Conn
+}
+
+// Write implements [net.Conn].
+func (c *Conn) Write(data []byte) (int, error) {
+	if c.config.Delay > 0 {
+		time.Sleep(c.config.Delay)
+	}
+	if c.config.Loss > 0 && rand.Float64() < c.config.Loss {
+		return len(data), nil
+	}
+	if c.config.Duplicate > 0 && rand.Float64() < c.config.Duplicate {
+		if _, err := c.Conn.Write(data); err != nil {
+			return 0, err
+		}
+	}
+	return c.Conn.Write(data)
+}
+
+// SyscallConn implements [syscall.Conn] so that socket options
+// remain accessible through the wrapper.
+func (c *Conn) SyscallConn() (syscall.RawConn, error) {
+	sc, ok := c.Conn.(syscall.Conn)
+	if !ok {
+		return nil, syscall.EINVAL
+	}
+	return sc.SyscallConn()
+}
@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package slogging
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// errInvalidFormat indicates that the log format is not supported.
+var errInvalidFormat = errors.New("invalid log format")
+
+// Setup configures the default logger to write to the standard error using
+// the given format (text or json) and level (debug, info, warn, or error).
+func Setup(format, level string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return err
+	}
+	options := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case "text":
+		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, options)))
+	case "json":
+		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, options)))
+	default:
+		return fmt.Errorf("%w: %s", errInvalidFormat, format)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+//go:build linux
+
+package sockopt
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// SetBacklog sets the accept queue size of a listening socket.
+//
+// Go calls listen(2) with the system maximum after the [net.ListenConfig]
+// Control callback runs, so we call listen(2) again on the listening
+// socket, which makes Linux update the backlog.
+func SetBacklog(conn syscall.RawConn, backlog int) error {
+	var soErr error
+	err := conn.Control(func(fd uintptr) {
+		soErr = unix.Listen(int(fd), backlog)
+	})
+	if err != nil {
+		return err
+	}
+	return soErr
+}
+
+// AcceptQueue returns the number of connections waiting in the accept
+// queue of a listening socket and the accept queue size.
+func AcceptQueue(conn syscall.RawConn) (queued, backlog int, err error) {
+	var (
+		info  *unix.TCPInfo
+		soErr error
+	)
+	err = conn.Control(func(fd uintptr) {
+		info, soErr = unix.GetsockoptTCPInfo(int(fd), unix.IPPROTO_TCP, unix.TCP_INFO)
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if soErr != nil {
+		return 0, 0, soErr
+	}
+	// For listening sockets, the kernel reports the accept queue
+	// length in tcpi_unacked and its size in tcpi_sacked.
+	return int(info.Unacked), int(info.Sacked), nil
+}
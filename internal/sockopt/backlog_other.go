@@ -0,0 +1,20 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+//go:build !linux
+
+package sockopt
+
+import (
+	"errors"
+	"syscall"
+)
+
+// SetBacklog returns [errors.ErrUnsupported] since we only implement it on Linux.
+func SetBacklog(conn syscall.RawConn, backlog int) error {
+	return errors.ErrUnsupported
+}
+
+// AcceptQueue returns [errors.ErrUnsupported] since TCP_INFO is Linux only.
+func AcceptQueue(conn syscall.RawConn) (queued, backlog int, err error) {
+	return 0, 0, errors.ErrUnsupported
+}
@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+//go:build linux
+
+package sockopt
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// SetCongestion sets the TCP congestion control algorithm.
+func SetCongestion(conn syscall.RawConn, algorithm string) error {
+	var soErr error
+	err := conn.Control(func(fd uintptr) {
+		soErr = unix.SetsockoptString(int(fd), unix.IPPROTO_TCP, unix.TCP_CONGESTION, algorithm)
+	})
+	if err != nil {
+		return err
+	}
+	return soErr
+}
+
+// GetCongestion gets the TCP congestion control algorithm.
+func GetCongestion(conn syscall.RawConn) (string, error) {
+	var (
+		algorithm string
+		soErr     error
+	)
+	err := conn.Control(func(fd uintptr) {
+		algorithm, soErr = unix.GetsockoptString(int(fd), unix.IPPROTO_TCP, unix.TCP_CONGESTION)
+	})
+	if err != nil {
+		return "", err
+	}
+	return algorithm, soErr
+}
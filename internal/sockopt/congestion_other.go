@@ -0,0 +1,20 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+//go:build !linux
+
+package sockopt
+
+import (
+	"errors"
+	"syscall"
+)
+
+// SetCongestion sets the TCP congestion control algorithm.
+func SetCongestion(conn syscall.RawConn, algorithm string) error {
+	return errors.ErrUnsupported
+}
+
+// GetCongestion gets the TCP congestion control algorithm.
+func GetCongestion(conn syscall.RawConn) (string, error) {
+	return "", errors.ErrUnsupported
+}
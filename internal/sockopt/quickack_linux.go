@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+//go:build linux
+
+package sockopt
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// SetQuickAck sets TCP_QUICKACK, which the kernel may later reset.
+func SetQuickAck(conn syscall.RawConn, enabled bool) error {
+	value := 0
+	if enabled {
+		value = 1
+	}
+	return SetInt(conn, unix.IPPROTO_TCP, unix.TCP_QUICKACK, value)
+}
+
+// GetQuickAck gets TCP_QUICKACK.
+func GetQuickAck(conn syscall.RawConn) (bool, error) {
+	value, err := GetInt(conn, unix.IPPROTO_TCP, unix.TCP_QUICKACK)
+	return value != 0, err
+}
@@ -0,0 +1,20 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+//go:build !linux
+
+package sockopt
+
+import (
+	"errors"
+	"syscall"
+)
+
+// SetQuickAck sets TCP_QUICKACK, which the kernel may later reset.
+func SetQuickAck(conn syscall.RawConn, enabled bool) error {
+	return errors.ErrUnsupported
+}
+
+// GetQuickAck gets TCP_QUICKACK.
+func GetQuickAck(conn syscall.RawConn) (bool, error) {
+	return false, errors.ErrUnsupported
+}
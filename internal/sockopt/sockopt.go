@@ -0,0 +1,23 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package sockopt contains helpers to get and set socket options.
+package sockopt
+
+import (
+	"crypto/tls"
+	"net"
+	"syscall"
+)
+
+// SyscallConn returns the [syscall.RawConn] underlying a connection
+// possibly wrapped by TLS.
+func SyscallConn(conn net.Conn) (syscall.RawConn, error) {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return nil, syscall.EINVAL
+	}
+	return sc.SyscallConn()
+}
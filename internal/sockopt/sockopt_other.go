@@ -0,0 +1,20 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+//go:build !unix
+
+package sockopt
+
+import (
+	"errors"
+	"syscall"
+)
+
+// SetInt sets an integer socket option.
+func SetInt(conn syscall.RawConn, level, name, value int) error {
+	return errors.ErrUnsupported
+}
+
+// GetInt gets an integer socket option.
+func GetInt(conn syscall.RawConn, level, name int) (int, error) {
+	return 0, errors.ErrUnsupported
+}
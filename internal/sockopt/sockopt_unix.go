@@ -0,0 +1,34 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+//go:build unix
+
+package sockopt
+
+import "syscall"
+
+// SetInt sets an integer socket option.
+func SetInt(conn syscall.RawConn, level, name, value int) error {
+	var soErr error
+	err := conn.Control(func(fd uintptr) {
+		soErr = syscall.SetsockoptInt(int(fd), level, name, value)
+	})
+	if err != nil {
+		return err
+	}
+	return soErr
+}
+
+// GetInt gets an integer socket option.
+func GetInt(conn syscall.RawConn, level, name int) (int, error) {
+	var (
+		value int
+		soErr error
+	)
+	err := conn.Control(func(fd uintptr) {
+		value, soErr = syscall.GetsockoptInt(int(fd), level, name)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return value, soErr
+}
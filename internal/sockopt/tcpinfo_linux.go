@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+//go:build linux
+
+package sockopt
+
+import (
+	"log/slog"
+	"net"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// TCPInfoAttr returns a log attribute containing the kernel TCP_INFO
+// statistics of the given connection or an empty attribute on failure.
+func TCPInfoAttr(conn net.Conn) slog.Attr {
+	info, err := getTCPInfo(conn)
+	if err != nil {
+		return slog.Attr{}
+	}
+	return slog.Group("tcpinfo",
+		slog.Int("retransmits", int(info.Total_retrans)),
+		slog.Duration("rtt", time.Duration(info.Rtt)*time.Microsecond),
+		slog.Duration("rttvar", time.Duration(info.Rttvar)*time.Microsecond),
+		slog.Int("sndCwnd", int(info.Snd_cwnd)),
+		slog.Int("sndMss", int(info.Snd_mss)),
+	)
+}
+
+// TCPInfoRTT returns the kernel smoothed RTT and RTT variance of conn.
+func TCPInfoRTT(conn net.Conn) (rtt, rttvar time.Duration, err error) {
+	info, err := getTCPInfo(conn)
+	if err != nil {
+		return 0, 0, err
+	}
+	return time.Duration(info.Rtt) * time.Microsecond, time.Duration(info.Rttvar) * time.Microsecond, nil
+}
+
+// getTCPInfo returns the kernel TCP_INFO statistics of conn.
+func getTCPInfo(conn net.Conn) (*unix.TCPInfo, error) {
+	if conn == nil {
+		return nil, syscall.EINVAL
+	}
+	rawConn, err := SyscallConn(conn)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		info  *unix.TCPInfo
+		soErr error
+	)
+	err = rawConn.Control(func(fd uintptr) {
+		info, soErr = unix.GetsockoptTCPInfo(int(fd), unix.IPPROTO_TCP, unix.TCP_INFO)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, soErr
+}
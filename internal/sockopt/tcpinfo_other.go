@@ -0,0 +1,22 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+//go:build !linux
+
+package sockopt
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"time"
+)
+
+// TCPInfoAttr returns an empty attribute since TCP_INFO is Linux only.
+func TCPInfoAttr(conn net.Conn) slog.Attr {
+	return slog.Attr{}
+}
+
+// TCPInfoRTT returns [errors.ErrUnsupported] since TCP_INFO is Linux only.
+func TCPInfoRTT(conn net.Conn) (rtt, rttvar time.Duration, err error) {
+	return 0, 0, errors.ErrUnsupported
+}
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package tracing exports OpenTelemetry spans using OTLP over HTTP.
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+)
+
+// Setup configures the global tracer provider to export spans to the
+// OTLP/HTTP collector at endpoint (e.g., http://localhost:4318) on behalf
+// of service, and returns the function flushing spans on shutdown.
+//
+// An endpoint without a path uses the standard /v1/traces path.
+func Setup(ctx context.Context, endpoint, service string) (func(context.Context) error, error) {
+	URL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if URL.Path == "" || URL.Path == "/" {
+		URL.Path = "/v1/traces"
+	}
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(URL.String()))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(service))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
+
+// Wrap returns a handler creating a span for each request, continuing
+// the trace propagated by the client using traceparent, if any.
+//
+// We name spans after the method only, since paths such as /api/{size}
+// would otherwise yield a span name for each size.
+func Wrap(next http.Handler, service string) http.Handler {
+	return otelhttp.NewHandler(next, service, otelhttp.WithSpanNameFormatter(
+		func(_ string, req *http.Request) string {
+			return req.Method
+		}))
+}
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"cmp"
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"slices"
+)
+
+// Capabilities describes what a server supports.
+//
+// Construct using [*Handler.Capabilities].
+type Capabilities struct {
+	Methods            []string `json:"methods"`
+	MaxSize            int64    `json:"maxSize"`
+	MaxDurationSeconds float64  `json:"maxDurationSeconds"`
+	FillModes          []string `json:"fillModes"`
+	Compression        []string `json:"compression"`
+	Protocols          []string `json:"protocols"`
+	TLSMinVersion      string   `json:"tlsMinVersion,omitempty"`
+	TLSMaxVersion      string   `json:"tlsMaxVersion,omitempty"`
+}
+
+// Capabilities returns the [*Capabilities] depending on the handler
+// configuration. Zero MaxSize and MaxDurationSeconds mean unlimited.
+func (h *Handler) Capabilities() *Capabilities {
+	caps := &Capabilities{
+		Methods:            []string{http.MethodGet, http.MethodPut, http.MethodPost},
+		MaxSize:            h.MaxSize,
+		MaxDurationSeconds: h.MaxDuration.Seconds(),
+		FillModes:          slices.Clone(FillModes),
+		Compression:        []string{},
+		Protocols:          []string{},
+	}
+	if h.AllowCompression {
+		caps.Compression = slices.Clone(supportedEncodings)
+	}
+	return caps
+}
+
+// SetTLSVersions sets the TLS version range using the given config,
+// where zero versions mean the crypto/tls defaults.
+func (c *Capabilities) SetTLSVersions(config *tls.Config) {
+	c.TLSMinVersion = tls.VersionName(cmp.Or(config.MinVersion, tls.VersionTLS12))
+	c.TLSMaxVersion = tls.VersionName(cmp.Or(config.MaxVersion, tls.VersionTLS13))
+}
+
+// HandleCapabilities handles GET /api/capabilities.
+func (c *Capabilities) HandleCapabilities(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(rw).Encode(c)
+}
@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"context"
+	"net"
+
+	"golang.org/x/time/rate"
+)
+
+// connKey is the context key for the accepted [net.Conn].
+type connKey struct{}
+
+// WithConn returns a copy of ctx carrying the given [net.Conn].
+func WithConn(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connKey{}, conn)
+}
+
+// contextConn returns the [net.Conn] stored into ctx, if any.
+func contextConn(ctx context.Context) net.Conn {
+	conn, _ := ctx.Value(connKey{}).(net.Conn)
+	return conn
+}
+
+// limiterKey is the context key for the per-connection [*rate.Limiter].
+type limiterKey struct{}
+
+// WithLimiter returns a copy of ctx carrying the given [*rate.Limiter].
+func WithLimiter(ctx context.Context, limiter *rate.Limiter) context.Context {
+	return context.WithValue(ctx, limiterKey{}, limiter)
+}
+
+// contextLimiter returns the [*rate.Limiter] stored into ctx, if any.
+func contextLimiter(ctx context.Context) *rate.Limiter {
+	limiter, _ := ctx.Value(limiterKey{}).(*rate.Limiter)
+	return limiter
+}
@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+// deadlineReader is an [io.Reader] extending the read deadline before each read.
+type deadlineReader struct {
+	rc      *http.ResponseController
+	r       io.Reader
+	timeout time.Duration
+}
+
+// newDeadlineReader returns a reader for the request body enforcing
+// [Handler.BodyReadTimeout], or the body itself when it is zero.
+func (h *Handler) newDeadlineReader(rw http.ResponseWriter, req *http.Request) io.Reader {
+	if h.BodyReadTimeout <= 0 {
+		return req.Body
+	}
+	return &deadlineReader{rc: http.NewResponseController(rw), r: req.Body, timeout: h.BodyReadTimeout}
+}
+
+// Read implements [io.Reader].
+func (dr *deadlineReader) Read(data []byte) (int, error) {
+	_ = dr.rc.SetReadDeadline(time.Now().Add(dr.timeout))
+	return dr.r.Read(data)
+}
@@ -0,0 +1,20 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"net/http"
+	"strings"
+)
+
+// wantsSHA256 returns whether the client asked for a SHA-256 digest of the body.
+func wantsSHA256(req *http.Request) bool {
+	for _, name := range []string{"Want-Repr-Digest", "Want-Digest"} {
+		for _, value := range req.Header.Values(name) {
+			if strings.Contains(strings.ToLower(value), "sha-256") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bassosimone/2026-02-js-perf/internal/geoip"
+)
+
+// HandleEcho handles POST /api/echo by streaming the request body back.
+func (h *Handler) HandleEcho(rw http.ResponseWriter, req *http.Request) {
+	logger := slog.With(slog.String("reqid", requestID(rw, req)))
+	if h.exceedsMaxSize(req.ContentLength) {
+		h.Metrics.observe(http.MethodPost, http.StatusRequestEntityTooLarge, 0, 0)
+		rw.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+	logger.Info("echo",
+		slog.Int64("contentLength", req.ContentLength),
+		slog.String("proto", req.Proto),
+		slog.String("alpn", TLSALPN(req)),
+		slog.String("remote", req.RemoteAddr),
+		geoip.FromContext(req.Context()).Attr(),
+	)
+
+	// The HTTP/1.1 server otherwise stops reading the request body
+	// once we start writing the response. Other protocols are always
+	// full duplex and return an error we can safely ignore.
+	_ = http.NewResponseController(rw).EnableFullDuplex()
+
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.Header().Set("Content-Type", DefaultContentType)
+	if req.ContentLength >= 0 {
+		rw.Header().Set("Content-Length", strconv.FormatInt(req.ContentLength, 10))
+	}
+	var body io.Reader = req.Body
+	if h.MaxSize > 0 {
+		body = io.LimitReader(body, h.MaxSize)
+	}
+	t0 := time.Now()
+	buf := h.getCopyBuffer()
+	defer h.putCopyBuffer(buf)
+	count, err := io.CopyBuffer(h.Metrics.liveWriter(rw), body, *buf)
+	elapsed := time.Since(t0)
+	logger.Info("echo done",
+		slog.Int64("bytes", count),
+		copyOutcomeAttrs(req.Context(), err),
+		slog.Duration("elapsed", elapsed),
+		slog.Float64("mbps", Speed(count, elapsed)/1e6),
+		slog.String("remote", req.RemoteAddr),
+	)
+	h.Metrics.observe(http.MethodPost, http.StatusOK, count, elapsed)
+}
@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// supportedEncodings lists the supported content codings by preference.
+var supportedEncodings = []string{"br", "gzip", "deflate"}
+
+// negotiateEncoding returns the content coding to use for the given
+// Accept-Encoding header value, or false when none is acceptable.
+func negotiateEncoding(header string) (string, bool) {
+	type coding struct {
+		name string
+		q    float64
+	}
+	var codings []coding
+	for part := range strings.SplitSeq(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		for param := range strings.SplitSeq(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.ToLower(key) != "q" {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				parsed = 0
+			}
+			q = parsed
+		}
+		codings = append(codings, coding{name, q})
+	}
+	listed := func(name string) bool {
+		return slices.ContainsFunc(codings, func(c coding) bool { return c.name == name })
+	}
+
+	// As in RFC 9110 Section 12.5.3, "*" matches the codings not listed
+	// elsewhere, including identity, so "gzip;q=0, *" does not select gzip.
+	var (
+		best     string
+		bestQ    float64
+		identity = true
+	)
+	for _, c := range codings {
+		names := []string{c.name}
+		if c.name == "*" {
+			names = slices.DeleteFunc(slices.Clone(supportedEncodings), listed)
+			if !listed("identity") {
+				identity = c.q > 0
+			}
+		}
+		if c.name == "identity" {
+			identity = c.q > 0
+		}
+		for _, name := range names {
+			if !slices.Contains(supportedEncodings, name) {
+				continue
+			}
+			// Ties are broken in favor of the first coding listed.
+			if c.q > bestQ {
+				best, bestQ = name, c.q
+			}
+		}
+	}
+	return best, best != "" || identity
+}
+
+// newEncoder wraps w with the encoder for the given content coding.
+func newEncoder(w io.Writer, encoding string) io.WriteCloser {
+	switch encoding {
+	case "br":
+		return brotli.NewWriter(w)
+	case "gzip":
+		return gzip.NewWriter(w)
+	case "deflate":
+		return zlib.NewWriter(w)
+	default:
+		return nopWriteCloser{w}
+	}
+}
+
+// nopWriteCloser adds a no-op Close method to an [io.Writer].
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close implements [io.Closer].
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// countingWriter is an [io.Writer] counting the bytes written.
+type countingWriter struct {
+	count int64
+	w     io.Writer
+}
+
+var _ io.Writer = &countingWriter{}
+
+// Write implements [io.Writer].
+func (cw *countingWriter) Write(data []byte) (int, error) {
+	count, err := cw.w.Write(data)
+	cw.count += int64(count)
+	return count, err
+}
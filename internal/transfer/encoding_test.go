@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import "testing"
+
+func TestNegotiateEncoding(t *testing.T) {
+	cases := []struct {
+		header     string
+		encoding   string
+		acceptable bool
+	}{
+		{"", "", true},
+		{"identity", "", true},
+		{"gzip", "gzip", true},
+		{"gzip, br", "gzip", true},
+		{"gzip;q=0.5, br", "br", true},
+		{"GZIP;Q=0.5, deflate;q=0.8", "deflate", true},
+		{"zstd", "", true},
+		{"gzip;q=0", "", true},
+		{"*", "br", true},
+		{"gzip;q=0, *", "br", true},
+		{"br;q=0, gzip;q=0, *", "deflate", true},
+		{"br;q=0, gzip;q=0, deflate;q=0, *", "", true},
+		{"gzip;q=0.5, *;q=0.1", "gzip", true},
+		{"identity;q=0", "", false},
+		{"identity;q=0, gzip", "gzip", true},
+		{"*;q=0", "", false},
+		{"*;q=0, identity", "", true},
+		{"*;q=0, deflate", "deflate", true},
+	}
+	for _, tc := range cases {
+		encoding, acceptable := negotiateEncoding(tc.header)
+		if encoding != tc.encoding || acceptable != tc.acceptable {
+			t.Errorf("%q: got (%q, %v), want (%q, %v)", tc.header, encoding, acceptable, tc.encoding, tc.acceptable)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"io"
+	"net/http"
+)
+
+// flushWriter is an [io.Writer] flushing the response after every interval bytes.
+type flushWriter struct {
+	interval int64
+	pending  int64
+	rc       *http.ResponseController
+	w        io.Writer
+}
+
+var _ io.Writer = &flushWriter{}
+
+// newFlushWriter returns a writer flushing rw after every interval
+// bytes or rw itself when interval is zero.
+func newFlushWriter(rw http.ResponseWriter, interval int64) io.Writer {
+	if interval <= 0 {
+		return rw
+	}
+	return &flushWriter{interval: interval, rc: http.NewResponseController(rw), w: rw}
+}
+
+// Write implements [io.Writer].
+func (fw *flushWriter) Write(data []byte) (int, error) {
+	var total int
+	for len(data) > 0 {
+		chunk := data[:min(int64(len(data)), fw.interval-fw.pending)]
+		count, err := fw.w.Write(chunk)
+		total += count
+		fw.pending += int64(count)
+		if err != nil {
+			return total, err
+		}
+		if fw.pending >= fw.interval {
+			fw.pending = 0
+			if err := fw.rc.Flush(); err != nil {
+				return total, err
+			}
+		}
+		data = data[count:]
+	}
+	return total, nil
+}
@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// Histograms accumulates the durations and sizes of completed transfers.
+//
+// Construct using [NewHistograms]. A nil [*Histograms] is valid.
+type Histograms struct {
+	methods map[string]*methodHistograms
+	mu      sync.Mutex
+}
+
+// methodHistograms contains the histograms for a method.
+type methodHistograms struct {
+	bytes     *hdrhistogram.Histogram
+	durations *hdrhistogram.Histogram // microseconds
+}
+
+// NewHistograms returns an empty [*Histograms].
+func NewHistograms() *Histograms {
+	return &Histograms{methods: map[string]*methodHistograms{}}
+}
+
+// record adds a transfer of count bytes that took elapsed, clamping
+// values beyond the tracked range of one hour and one TB.
+func (hs *Histograms) record(method string, count int64, elapsed time.Duration) {
+	if hs == nil {
+		return
+	}
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	mh := hs.methods[method]
+	if mh == nil {
+		mh = &methodHistograms{
+			bytes:     hdrhistogram.New(1, 1e12, 3),
+			durations: hdrhistogram.New(1, time.Hour.Microseconds(), 3),
+		}
+		hs.methods[method] = mh
+	}
+	_ = mh.bytes.RecordValue(min(count, mh.bytes.HighestTrackableValue()))
+	_ = mh.durations.RecordValue(min(elapsed.Microseconds(), mh.durations.HighestTrackableValue()))
+}
+
+// Percentiles summarizes a distribution.
+type Percentiles struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// newPercentiles returns the [Percentiles] of hg divided by unit.
+func newPercentiles(hg *hdrhistogram.Histogram, unit float64) Percentiles {
+	return Percentiles{
+		P50: float64(hg.ValueAtQuantile(50)) / unit,
+		P90: float64(hg.ValueAtQuantile(90)) / unit,
+		P99: float64(hg.ValueAtQuantile(99)) / unit,
+		Max: float64(hg.Max()) / unit,
+	}
+}
+
+// HistogramSummary summarizes the transfers using a method.
+type HistogramSummary struct {
+	Count    int64       `json:"count"`
+	Duration Percentiles `json:"durationSeconds"`
+	Bytes    Percentiles `json:"bytes"`
+}
+
+// Snapshot returns the summary of the transfers by method.
+func (hs *Histograms) Snapshot() map[string]HistogramSummary {
+	summaries := map[string]HistogramSummary{}
+	if hs == nil {
+		return summaries
+	}
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	for method, mh := range hs.methods {
+		summaries[method] = HistogramSummary{
+			Count:    mh.durations.TotalCount(),
+			Duration: newPercentiles(mh.durations, 1e6),
+			Bytes:    newPercentiles(mh.bytes, 1),
+		}
+	}
+	return summaries
+}
+
+// WriteFile writes the [Histograms.Snapshot] as JSON to path.
+func (hs *Histograms) WriteFile(path string) error {
+	data, err := json.MarshalIndent(hs.Snapshot(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"context"
+	"io"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// liveStats contains the gauges that [*Metrics] updates while transfers
+// are in progress rather than once they complete.
+type liveStats struct {
+	bytes        atomic.Int64
+	closedConns  atomic.Int64
+	connRequests atomic.Int64
+	conns        atomic.Int64
+	rate         atomic.Uint64 // float64 bits
+}
+
+// ConnOpened records that a new connection has been accepted.
+func (m *Metrics) ConnOpened() {
+	if m != nil {
+		m.live.conns.Add(1)
+	}
+}
+
+// ConnClosed records that an accepted connection has been closed
+// after serving the given number of requests.
+func (m *Metrics) ConnClosed(requests int64) {
+	if m != nil {
+		m.live.conns.Add(-1)
+		m.live.closedConns.Add(1)
+		m.live.connRequests.Add(requests)
+	}
+}
+
+// requestsPerConn returns the average number of requests served
+// by the closed connections, or zero if none has been closed.
+func (ls *liveStats) requestsPerConn() float64 {
+	closed := ls.closedConns.Load()
+	if closed <= 0 {
+		return 0
+	}
+	return float64(ls.connRequests.Load()) / float64(closed)
+}
+
+// Sample updates the goodput gauge every interval, using the bytes moved
+// by all active transfers since the previous tick, until ctx is done.
+func (m *Metrics) Sample(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	prevBytes, prevTime := m.live.bytes.Load(), time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			bytes := m.live.bytes.Load()
+			rate := float64(bytes-prevBytes) / now.Sub(prevTime).Seconds()
+			m.live.rate.Store(math.Float64bits(rate))
+			prevBytes, prevTime = bytes, now
+		}
+	}
+}
+
+// liveWriter returns an [io.Writer] adding the bytes written to w
+// to the live byte counter, or w itself when m is nil.
+func (m *Metrics) liveWriter(w io.Writer) io.Writer {
+	if m == nil {
+		return w
+	}
+	return &liveCounter{m: m, w: w}
+}
+
+// liveReader is like liveWriter but for the bytes read from r.
+func (m *Metrics) liveReader(r io.Reader) io.Reader {
+	if m == nil {
+		return r
+	}
+	return &liveCounter{m: m, r: r}
+}
+
+// liveCounter adds the bytes moved through r or w to the live byte counter.
+type liveCounter struct {
+	m *Metrics
+	r io.Reader
+	w io.Writer
+}
+
+// Read implements [io.Reader].
+func (lc *liveCounter) Read(data []byte) (int, error) {
+	count, err := lc.r.Read(data)
+	lc.m.live.bytes.Add(int64(count))
+	return count, err
+}
+
+// Write implements [io.Writer].
+func (lc *liveCounter) Write(data []byte) (int, error) {
+	count, err := lc.w.Write(data)
+	lc.m.live.bytes.Add(int64(count))
+	return count, err
+}
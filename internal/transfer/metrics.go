@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// durationBuckets contains the upper bounds, in seconds, of the
+// transfer duration histogram buckets.
+var durationBuckets = []float64{0.001, 0.01, 0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// histogram is a cumulative histogram in the Prometheus style.
+type histogram struct {
+	counts []int64
+	count  int64
+	sum    float64
+}
+
+// observe adds a value to the histogram.
+func (hg *histogram) observe(value float64) {
+	if hg.counts == nil {
+		hg.counts = make([]int64, len(durationBuckets))
+	}
+	for idx, bound := range durationBuckets {
+		if value <= bound {
+			hg.counts[idx]++
+		}
+	}
+	hg.count++
+	hg.sum += value
+}
+
+// requestKey identifies a request counter.
+type requestKey struct {
+	method string
+	status int
+}
+
+// Metrics collects server metrics in the Prometheus text format.
+//
+// The zero value is ready to use. A nil [*Metrics] is valid.
+type Metrics struct {
+	durations map[string]*histogram
+	getBytes  int64
+	live      liveStats
+	mu        sync.Mutex
+	putBytes  int64
+	requests  map[requestKey]int64
+}
+
+// observe records the outcome of a request.
+func (m *Metrics) observe(method string, status int, count int64, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.requests == nil {
+		m.requests = make(map[requestKey]int64)
+		m.durations = make(map[string]*histogram)
+	}
+	m.requests[requestKey{method, status}]++
+	switch method {
+	case http.MethodGet:
+		m.getBytes += count
+	case http.MethodPut, http.MethodPost:
+		m.putBytes += count
+	}
+	if status >= 200 && status < 300 {
+		hg := m.durations[method]
+		if hg == nil {
+			hg = &histogram{}
+			m.durations[method] = hg
+		}
+		hg.observe(elapsed.Seconds())
+	}
+}
+
+// writeTo writes the metrics using the Prometheus text exposition format.
+func (m *Metrics) writeTo(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP jsperf_get_bytes_total Bytes sent by GET /api/{size}.\n")
+	fmt.Fprintf(w, "# TYPE jsperf_get_bytes_total counter\n")
+	fmt.Fprintf(w, "jsperf_get_bytes_total %d\n", m.getBytes)
+
+	fmt.Fprintf(w, "# HELP jsperf_put_bytes_total Bytes received by PUT and POST /api/{size}.\n")
+	fmt.Fprintf(w, "# TYPE jsperf_put_bytes_total counter\n")
+	fmt.Fprintf(w, "jsperf_put_bytes_total %d\n", m.putBytes)
+
+	fmt.Fprintf(w, "# HELP jsperf_goodput_bytes_per_second Aggregate goodput of the active transfers.\n")
+	fmt.Fprintf(w, "# TYPE jsperf_goodput_bytes_per_second gauge\n")
+	fmt.Fprintf(w, "jsperf_goodput_bytes_per_second %g\n", math.Float64frombits(m.live.rate.Load()))
+
+	fmt.Fprintf(w, "# HELP jsperf_active_connections Connections currently open.\n")
+	fmt.Fprintf(w, "# TYPE jsperf_active_connections gauge\n")
+	fmt.Fprintf(w, "jsperf_active_connections %d\n", m.live.conns.Load())
+
+	fmt.Fprintf(w, "# HELP jsperf_requests_per_connection Average requests served by the closed connections.\n")
+	fmt.Fprintf(w, "# TYPE jsperf_requests_per_connection gauge\n")
+	fmt.Fprintf(w, "jsperf_requests_per_connection %g\n", m.live.requestsPerConn())
+
+	fmt.Fprintf(w, "# HELP jsperf_requests_total Requests by method and status.\n")
+	fmt.Fprintf(w, "# TYPE jsperf_requests_total counter\n")
+	keys := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b requestKey) int {
+		return cmp.Or(cmp.Compare(a.method, b.method), cmp.Compare(a.status, b.status))
+	})
+	for _, key := range keys {
+		fmt.Fprintf(w, "jsperf_requests_total{method=%q,status=\"%d\"} %d\n",
+			key.method, key.status, m.requests[key])
+	}
+
+	fmt.Fprintf(w, "# HELP jsperf_transfer_duration_seconds Duration of successful transfers.\n")
+	fmt.Fprintf(w, "# TYPE jsperf_transfer_duration_seconds histogram\n")
+	methods := make([]string, 0, len(m.durations))
+	for method := range m.durations {
+		methods = append(methods, method)
+	}
+	slices.Sort(methods)
+	for _, method := range methods {
+		hg := m.durations[method]
+		for idx, bound := range durationBuckets {
+			fmt.Fprintf(w, "jsperf_transfer_duration_seconds_bucket{method=%q,le=%q} %d\n",
+				method, strconv.FormatFloat(bound, 'g', -1, 64), hg.counts[idx])
+		}
+		fmt.Fprintf(w, "jsperf_transfer_duration_seconds_bucket{method=%q,le=\"+Inf\"} %d\n", method, hg.count)
+		fmt.Fprintf(w, "jsperf_transfer_duration_seconds_sum{method=%q} %g\n", method, hg.sum)
+		fmt.Fprintf(w, "jsperf_transfer_duration_seconds_count{method=%q} %d\n", method, hg.count)
+	}
+}
+
+// HandleMetrics serves the metrics.
+func (m *Metrics) HandleMetrics(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.writeTo(rw)
+}
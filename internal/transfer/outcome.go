@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"syscall"
+)
+
+// copyOutcome classifies why a copy loop ended.
+func copyOutcome(ctx context.Context, err error) string {
+	switch {
+	case err == nil:
+		return "eof"
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE), errors.Is(err, net.ErrClosed):
+		return "reset"
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return "unexpected-eof"
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		return "timeout"
+	case ctx.Err() != nil, errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	default:
+		return "error"
+	}
+}
+
+// copyOutcomeAttrs returns the log attributes describing how a copy loop ended.
+func copyOutcomeAttrs(ctx context.Context, err error) slog.Attr {
+	return slog.Group("end", slog.String("reason", copyOutcome(ctx, err)), slog.Any("err", err))
+}
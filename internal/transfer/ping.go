@@ -0,0 +1,25 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// pingBody is the fixed body returned by GET /api/ping.
+const pingBody = "pong\n"
+
+// HandlePing handles GET /api/ping by returning a tiny fixed body.
+func (h *Handler) HandlePing(rw http.ResponseWriter, req *http.Request) {
+	t0 := time.Now()
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.Header().Set("Content-Length", strconv.Itoa(len(pingBody)))
+	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	rw.Write([]byte(pingBody))
+	if req.URL.Query().Get("probe") == "1" {
+		http.NewResponseController(rw).Flush()
+		h.Summary.recordProbe(req, time.Since(t0))
+	}
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// minRampInterval is the minimum sampling interval honored by ?ramp=.
+const minRampInterval = 10 * time.Millisecond
+
+// errInvalidRampInterval indicates that the ?ramp= interval is invalid.
+var errInvalidRampInterval = errors.New("invalid ramp interval")
+
+// RampSample is the number of bytes sent since the beginning of a GET
+// transfer, sampled periodically to visualize congestion control.
+type RampSample struct {
+	Elapsed float64 `json:"elapsedSeconds"`
+	Bytes   int64   `json:"bytes"`
+}
+
+// rampInterval returns the sampling interval requested using ?ramp=
+// (e.g., ?ramp=100ms) or zero when sampling is not requested.
+func rampInterval(req *http.Request) (time.Duration, error) {
+	value := req.URL.Query().Get("ramp")
+	if value == "" {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < minRampInterval {
+		return 0, errInvalidRampInterval
+	}
+	return interval, nil
+}
+
+// rampSampler periodically samples the bytes written through it.
+//
+// A nil [*rampSampler] is valid.
+type rampSampler struct {
+	bytes    atomic.Int64
+	done     chan struct{}
+	interval time.Duration
+	samples  []RampSample
+	stop     chan struct{}
+	t0       time.Time
+	w        io.Writer
+}
+
+// newRampSampler returns a [*rampSampler] using the given interval
+// or nil when interval is zero.
+func newRampSampler(interval time.Duration) *rampSampler {
+	if interval <= 0 {
+		return nil
+	}
+	return &rampSampler{
+		done:     make(chan struct{}),
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// wrap starts sampling and returns a writer counting the bytes written to w.
+func (rs *rampSampler) wrap(w io.Writer) io.Writer {
+	if rs == nil {
+		return w
+	}
+	rs.t0, rs.w = time.Now(), w
+	go rs.loop()
+	return rs
+}
+
+// Write implements [io.Writer].
+func (rs *rampSampler) Write(data []byte) (int, error) {
+	count, err := rs.w.Write(data)
+	rs.bytes.Add(int64(count))
+	return count, err
+}
+
+// loop samples the byte count at each tick until stopped.
+func (rs *rampSampler) loop() {
+	defer close(rs.done)
+	ticker := time.NewTicker(rs.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-rs.stop:
+			return
+		case now := <-ticker.C:
+			rs.samples = append(rs.samples, RampSample{
+				Elapsed: now.Sub(rs.t0).Seconds(),
+				Bytes:   rs.bytes.Load(),
+			})
+		}
+	}
+}
+
+// finish stops sampling and returns the samples, including a final
+// sample taken at the end of the transfer.
+func (rs *rampSampler) finish() []RampSample {
+	if rs == nil {
+		return nil
+	}
+	close(rs.stop)
+	<-rs.done
+	return append(rs.samples, RampSample{
+		Elapsed: time.Since(rs.t0).Seconds(),
+		Bytes:   rs.bytes.Load(),
+	})
+}
@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/time/rate"
+)
+
+// ParseRate parses a rate such as `10Mbit` or `2MB/s` into bytes per second.
+func ParseRate(value string) (float64, error) {
+	spec := strings.TrimSuffix(value, "/s")
+	var unit float64
+	switch {
+	case strings.HasSuffix(spec, "bit"):
+		spec, unit = strings.TrimSuffix(spec, "bit"), 1.0/8
+	case strings.HasSuffix(spec, "bps"):
+		spec, unit = strings.TrimSuffix(spec, "bps"), 1.0/8
+	case strings.HasSuffix(spec, "B"):
+		spec, unit = strings.TrimSuffix(spec, "B"), 1
+	default:
+		return 0, fmt.Errorf("invalid rate %q: missing `bit` or `B` unit", value)
+	}
+	for _, prefix := range []struct {
+		name  string
+		scale float64
+	}{
+		{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30},
+		{"k", 1e3}, {"K", 1e3}, {"M", 1e6}, {"G", 1e9},
+	} {
+		if trimmed, found := strings.CutSuffix(spec, prefix.name); found {
+			spec, unit = trimmed, unit*prefix.scale
+			break
+		}
+	}
+	number, err := strconv.ParseFloat(spec, 64)
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("invalid rate %q", value)
+	}
+	return number * unit, nil
+}
+
+// NewLimiter returns a token-bucket limiter for the given rate in bytes per
+// second, whose burst size corresponds to ~100 ms worth of data.
+func NewLimiter(bytesPerSecond float64) *rate.Limiter {
+	burst := min(max(int(bytesPerSecond/10), 1<<14), 1<<20)
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), burst)
+}
+
+// rateWriter is an [io.Writer] pacing writes using a [*rate.Limiter].
+type rateWriter struct {
+	ctx     context.Context
+	limiter *rate.Limiter
+	w       io.Writer
+}
+
+var _ io.Writer = &rateWriter{}
+
+// Write implements [io.Writer].
+func (rw *rateWriter) Write(data []byte) (int, error) {
+	var total int
+	for len(data) > 0 {
+		chunk := data[:min(len(data), rw.limiter.Burst())]
+		if err := rw.limiter.WaitN(rw.ctx, len(chunk)); err != nil {
+			return total, err
+		}
+		count, err := rw.w.Write(chunk)
+		total += count
+		if err != nil {
+			return total, err
+		}
+		data = data[count:]
+	}
+	return total, nil
+}
@@ -0,0 +1,25 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// maxRequestIDLength is the maximum length of a client-provided request ID.
+const maxRequestIDLength = 128
+
+// requestID returns the client-provided X-Request-ID, if reasonable,
+// or otherwise a new random request ID, and echoes it in the response.
+func requestID(rw http.ResponseWriter, req *http.Request) string {
+	id := req.Header.Get("X-Request-ID")
+	if id == "" || len(id) > maxRequestIDLength {
+		var buf [8]byte
+		rand.Read(buf[:])
+		id = hex.EncodeToString(buf[:])
+	}
+	rw.Header().Set("X-Request-ID", id)
+	return id
+}
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Results writes a JSON line per completed transfer.
+//
+// Construct using [OpenResults]. A nil [*Results] is valid.
+type Results struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// OpenResults opens the results file at path, or stdout when path is "-".
+func OpenResults(path string) (*Results, error) {
+	if path == "-" {
+		return &Results{w: os.Stdout}, nil
+	}
+	filep, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &Results{w: filep}, nil
+}
+
+// Result is the record of a completed transfer.
+type Result struct {
+	Time      time.Time `json:"ts"`
+	Method    string    `json:"method"`
+	Size      int64     `json:"size"`
+	Bytes     int64     `json:"bytes"`
+	Millis    float64   `json:"ms"`
+	Mbps      float64   `json:"mbps"`
+	Proto     string    `json:"proto"`
+	Remote    string    `json:"remote"`
+	RequestID string    `json:"reqid"`
+}
+
+// record writes the result of a transfer of count out of size bytes
+// that took elapsed, using the X-Request-ID we set on rw.
+func (r *Results) record(rw http.ResponseWriter, req *http.Request, size, count int64, elapsed time.Duration) {
+	if r == nil {
+		return
+	}
+	data, err := json.Marshal(Result{
+		Time:      time.Now(),
+		Method:    req.Method,
+		Size:      size,
+		Bytes:     count,
+		Millis:    float64(elapsed.Microseconds()) / 1e3,
+		Mbps:      Speed(count, elapsed) / 1e6,
+		Proto:     req.Proto,
+		Remote:    req.RemoteAddr,
+		RequestID: rw.Header().Get("X-Request-ID"),
+	})
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.w.Write(append(data, '\n')); err != nil {
+		slog.Warn("cannot write result", slog.Any("err", err))
+	}
+}
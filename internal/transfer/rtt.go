@@ -0,0 +1,30 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/bassosimone/2026-02-js-perf/internal/sockopt"
+)
+
+// rttResponse is the JSON body returned by GET /api/rtt.
+type rttResponse struct {
+	RTT    int64 `json:"rtt_us"`
+	RTTVar int64 `json:"rttvar_us"`
+}
+
+// HandleRTT handles GET /api/rtt by returning the kernel smoothed RTT.
+func (h *Handler) HandleRTT(rw http.ResponseWriter, req *http.Request) {
+	rtt, rttvar, err := sockopt.TCPInfoRTT(contextConn(req.Context()))
+	if err != nil {
+		slog.Info("RTT unavailable", slog.Any("err", err), slog.String("remote", req.RemoteAddr))
+		rw.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(rw).Encode(&rttResponse{RTT: rtt.Microseconds(), RTTVar: rttvar.Microseconds()})
+}
@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// errServeFileTooShort indicates that [Handler.ServeFile] is shorter
+// than the requested GET size.
+var errServeFileTooShort = errors.New("serve file too short")
+
+// openServeFile opens [Handler.ServeFile] failing if shorter than size.
+func (h *Handler) openServeFile(size int64) (*os.File, error) {
+	filep, err := os.Open(h.ServeFile)
+	if err != nil {
+		return nil, err
+	}
+	info, err := filep.Stat()
+	if err != nil {
+		filep.Close()
+		return nil, err
+	}
+	if info.Size() < size {
+		filep.Close()
+		return nil, errServeFileTooShort
+	}
+	return filep, nil
+}
+
+// newServeFileReader returns a reader for length bytes of filep starting at offset.
+func newServeFileReader(filep *os.File, offset, length int64) (io.Reader, error) {
+	if _, err := filep.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return io.LimitReader(filep, length), nil
+}
@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bassosimone/2026-02-js-perf/internal/humanize"
+)
+
+// sessionIDHeader groups parallel transfers belonging to the same test.
+const sessionIDHeader = "X-Session-ID"
+
+// maxSessionIDLength bounds the length of the session ID we accept.
+const maxSessionIDLength = 128
+
+// Sessions aggregates the transfers sharing the same X-Session-ID.
+//
+// Construct using [NewSessions]. A nil [*Sessions] is valid.
+type Sessions struct {
+	idle     time.Duration
+	mu       sync.Mutex
+	sessions map[string]*session
+	summary  *Summary
+}
+
+// session contains the aggregate state of a session.
+type session struct {
+	active  int
+	bytes   int64
+	first   time.Time
+	last    time.Time
+	method  string
+	streams int
+	timer   *time.Timer
+}
+
+// NewSessions returns a [*Sessions] ending sessions after idle and
+// recording them into summary, which may be nil.
+func NewSessions(idle time.Duration, summary *Summary) *Sessions {
+	return &Sessions{idle: idle, sessions: map[string]*session{}, summary: summary}
+}
+
+// sessionStream is a transfer belonging to a session.
+//
+// A nil [*sessionStream] is valid.
+type sessionStream struct {
+	id string
+	s  *Sessions
+}
+
+// begin registers the start of a transfer for the session of req.
+func (s *Sessions) begin(req *http.Request) *sessionStream {
+	id := req.Header.Get(sessionIDHeader)
+	if s == nil || id == "" || len(id) > maxSessionIDLength {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess := s.sessions[id]
+	if sess == nil {
+		sess = &session{first: time.Now(), method: req.Method}
+		s.sessions[id] = sess
+	}
+	if sess.timer != nil {
+		sess.timer.Stop()
+		sess.timer = nil
+	}
+	sess.active++
+	sess.streams++
+	return &sessionStream{id: id, s: s}
+}
+
+// end registers the end of a transfer that moved count bytes.
+func (st *sessionStream) end(count int64) {
+	if st == nil {
+		return
+	}
+	st.s.mu.Lock()
+	defer st.s.mu.Unlock()
+	sess := st.s.sessions[st.id]
+	sess.active--
+	sess.bytes += count
+	sess.last = time.Now()
+	if sess.active <= 0 {
+		sess.timer = time.AfterFunc(st.s.idle, func() { st.s.flush(st.id, sess) })
+	}
+}
+
+// flush ends the given session unless it became active again.
+func (s *Sessions) flush(id string, sess *session) {
+	s.mu.Lock()
+	if s.sessions[id] != sess || sess.active > 0 {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.sessions, id)
+	s.mu.Unlock()
+
+	elapsed := sess.last.Sub(sess.first)
+	slog.Info("session done",
+		slog.String("session", id),
+		slog.String("method", sess.method),
+		slog.Int("streams", sess.streams),
+		slog.Int64("bytes", sess.bytes),
+		slog.Duration("elapsed", elapsed),
+		slog.String("speed", humanize.SI(Speed(sess.bytes, elapsed), "bit/s")),
+		slog.Float64("mbps", Speed(sess.bytes, elapsed)/1e6),
+	)
+	s.summary.add(SummaryEntry{
+		Time:     sess.last,
+		Method:   sess.method,
+		Size:     -1,
+		Bytes:    sess.bytes,
+		Duration: elapsed.Seconds(),
+		Mbps:     Speed(sess.bytes, elapsed) / 1e6,
+		Session:  id,
+		Streams:  sess.streams,
+	})
+}
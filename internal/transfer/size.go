@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// errInvalidSize indicates that a size cannot be parsed.
+var errInvalidSize = errors.New("invalid size")
+
+// sizeUnits maps lowercase size suffixes to their multipliers.
+var sizeUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"m":   1e6,
+	"mb":  1e6,
+	"g":   1e9,
+	"gb":  1e9,
+	"t":   1e12,
+	"tb":  1e12,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+// requestSize returns the size of GET and PUT requests, taken from the
+// path (/api/{size}) or else from the query (/api?size=...).
+func requestSize(req *http.Request) string {
+	return cmp.Or(req.PathValue("size"), req.URL.Query().Get("size"))
+}
+
+// ParseSize parses a size in bytes with an optional SI or IEC suffix.
+func ParseSize(value string) (int64, error) {
+	if count, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if count < 0 {
+			return 0, errInvalidSize
+		}
+		return count, nil
+	}
+	idx := strings.IndexFunc(value, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if idx <= 0 {
+		return 0, errInvalidSize
+	}
+	unit, found := sizeUnits[strings.ToLower(value[idx:])]
+	if !found {
+		return 0, errInvalidSize
+	}
+	number, err := strconv.ParseFloat(value[:idx], 64)
+	if err != nil {
+		return 0, errInvalidSize
+	}
+	size := math.Round(number * unit)
+	if size < 0 || size >= math.MaxInt64 {
+		return 0, errInvalidSize
+	}
+	return int64(size), nil
+}
@@ -0,0 +1,27 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// setSpanAttributes annotates the request span, if tracing is enabled.
+func setSpanAttributes(req *http.Request, size, count int64, elapsed time.Duration) {
+	span := trace.SpanFromContext(req.Context())
+	if !span.IsRecording() {
+		return
+	}
+	span.SetAttributes(
+		attribute.Int64("jsperf.size", size),
+		attribute.Int64("jsperf.bytes", count),
+		attribute.Float64("jsperf.duration_seconds", elapsed.Seconds()),
+		attribute.String("jsperf.proto", req.Proto),
+		attribute.String("jsperf.alpn", TLSALPN(req)),
+		attribute.String("jsperf.remote", req.RemoteAddr),
+	)
+}
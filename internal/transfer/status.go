@@ -0,0 +1,43 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+)
+
+// maxStatusBody is the maximum body length honored by GET /api/status/{code}.
+const maxStatusBody = 1024
+
+// HandleStatus handles GET /api/status/{code} by returning the given status.
+func (h *Handler) HandleStatus(rw http.ResponseWriter, req *http.Request) {
+	code, err := strconv.Atoi(req.PathValue("code"))
+	body := req.URL.Query().Get("body")
+	if err != nil || code < 200 || code > 599 || len(body) > maxStatusBody {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	slog.Info("status", slog.Int("code", code), slog.String("remote", req.RemoteAddr))
+	rw.Header().Set("Cache-Control", "no-store")
+	if body != "" {
+		rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		rw.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	rw.WriteHeader(code)
+	rw.Write([]byte(body))
+}
+
+// injectError returns true, after responding with 500, when the request
+// is selected for failure according to [Handler.ErrorRate].
+func (h *Handler) injectError(rw http.ResponseWriter, req *http.Request, logger *slog.Logger) bool {
+	if h.ErrorRate <= 0 || rand.Float64() >= h.ErrorRate {
+		return false
+	}
+	logger.Info("GET error injected", slog.String("remote", req.RemoteAddr))
+	h.Metrics.observe(http.MethodGet, http.StatusInternalServerError, 0, 0)
+	rw.WriteHeader(http.StatusInternalServerError)
+	return true
+}
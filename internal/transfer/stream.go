@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bassosimone/2026-02-js-perf/internal/geoip"
+	"github.com/bassosimone/2026-02-js-perf/internal/humanize"
+	"github.com/bassosimone/2026-02-js-perf/internal/sockopt"
+)
+
+// infiniteSize is the size path value requesting an infinite GET.
+const infiniteSize = "infinite"
+
+// HandleGetDuration handles GET /api/duration/{seconds} by sending
+// bytes for the given wall-clock time, as in time-based speed tests.
+func (h *Handler) HandleGetDuration(rw http.ResponseWriter, req *http.Request) {
+	logger := slog.With(slog.String("reqid", requestID(rw, req)))
+	seconds, err := strconv.ParseFloat(req.PathValue("seconds"), 64)
+	if err != nil || math.IsNaN(seconds) || math.IsInf(seconds, 0) || seconds <= 0 {
+		h.Metrics.observe(http.MethodGet, http.StatusBadRequest, 0, 0)
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	duration := time.Duration(seconds * float64(time.Second))
+	if h.MaxDuration > 0 && duration > h.MaxDuration {
+		h.Metrics.observe(http.MethodGet, http.StatusBadRequest, 0, 0)
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	h.handleGetStream(rw, req, logger, duration)
+}
+
+// handleGetStream sends bytes until the client disconnects or, when
+// duration is positive, until duration has elapsed.
+func (h *Handler) handleGetStream(rw http.ResponseWriter, req *http.Request, logger *slog.Logger, duration time.Duration) {
+	contentType, err := h.contentType(req)
+	if err != nil {
+		h.Metrics.observe(http.MethodGet, http.StatusBadRequest, 0, 0)
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	ramp, err := rampInterval(req)
+	if err != nil {
+		h.Metrics.observe(http.MethodGet, http.StatusBadRequest, 0, 0)
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	logger.Info("GET stream",
+		slog.Duration("duration", duration),
+		slog.String("proto", req.Proto),
+		slog.String("alpn", TLSALPN(req)),
+		slog.String("remote", req.RemoteAddr),
+		geoip.FromContext(req.Context()).Attr(),
+	)
+	h.setConnectionClose(rw, req, logger)
+	if err := sleep(req.Context(), h.ResponseDelay+h.FirstByteDelay); err != nil {
+		logger.Info("GET interrupted", slog.Any("err", err), slog.String("remote", req.RemoteAddr))
+		return
+	}
+
+	ctx := req.Context()
+	if duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, duration)
+		defer cancel()
+	}
+	out := h.Metrics.liveWriter(&contextWriter{ctx: ctx, w: rw})
+	if limiter := contextLimiter(req.Context()); limiter != nil {
+		out = &rateWriter{ctx: ctx, limiter: limiter, w: out}
+	}
+	sampler := newRampSampler(ramp)
+	wire := &countingWriter{w: sampler.wrap(out)}
+
+	// Without Content-Length, net/http uses chunked encoding (or plain
+	// DATA frames for HTTP/2 and HTTP/3), and returning from the handler
+	// once the deadline expires terminates the body cleanly.
+	stream := h.Sessions.begin(req)
+	t0 := time.Now()
+	rw.Header().Set("Content-Type", contentType)
+	trailers := wantsTrailers(req)
+	if trailers {
+		announceTrailers(rw)
+	}
+	rw.WriteHeader(http.StatusOK)
+	http.NewResponseController(rw).Flush()
+	buf := h.getCopyBuffer()
+	defer h.putCopyBuffer(buf)
+	_, err = io.CopyBuffer(wire, h.newFillReader(0), *buf)
+	if errors.Is(err, context.DeadlineExceeded) && req.Context().Err() == nil {
+		err = nil
+	}
+	elapsed := time.Since(t0)
+	samples := sampler.finish()
+	if trailers {
+		setTrailers(rw, wire.count, elapsed)
+	}
+	stream.end(wire.count)
+	logger.Info("GET done",
+		slog.Int64("bytes", wire.count),
+		copyOutcomeAttrs(req.Context(), err),
+		slog.Duration("elapsed", elapsed),
+		slog.String("speed", humanize.SI(Speed(wire.count, elapsed), "bit/s")),
+		slog.Float64("mbps", Speed(wire.count, elapsed)/1e6),
+		slog.Float64("mibps", mibps(wire.count, elapsed)),
+		slog.String("remote", req.RemoteAddr),
+		sockopt.TCPInfoAttr(contextConn(req.Context())),
+	)
+	h.Metrics.observe(http.MethodGet, http.StatusOK, wire.count, elapsed)
+	h.Summary.record(req, -1, wire.count, elapsed, samples)
+	h.Histograms.record(req.Method, wire.count, elapsed)
+	h.Results.record(rw, req, -1, wire.count, elapsed)
+	setSpanAttributes(req, -1, wire.count, elapsed)
+}
+
+// contextWriter is an [io.Writer] failing once ctx is done.
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+var _ io.Writer = &contextWriter{}
+
+// Write implements [io.Writer].
+func (cw *contextWriter) Write(data []byte) (int, error) {
+	if err := cw.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cw.w.Write(data)
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bassosimone/2026-02-js-perf/internal/geoip"
+)
+
+// SummaryEntry describes a completed transfer.
+type SummaryEntry struct {
+	Time     time.Time `json:"time"`
+	Method   string    `json:"method"`
+	Size     int64     `json:"size"`
+	Bytes    int64     `json:"bytes"`
+	Duration float64   `json:"durationSeconds"`
+	Mbps     float64   `json:"mbps"`
+	Remote   string    `json:"remote"`
+	ALPN     string    `json:"alpn"`
+	Session  string    `json:"session,omitempty"`
+	Streams  int       `json:"streams,omitempty"`
+	Country  string    `json:"country,omitempty"`
+	ASN      uint      `json:"asn,omitempty"`
+
+	// Probe is true for GET /api/ping?probe=1, whose duration is the
+	// service latency rather than the transfer duration.
+	Probe bool `json:"probe,omitempty"`
+
+	// Samples contains the GET ?ramp= samples, if requested.
+	Samples []RampSample `json:"samples,omitempty"`
+}
+
+// Summary keeps the most recent completed transfers in a ring buffer.
+//
+// Construct using [NewSummary]. A nil [*Summary] is valid.
+type Summary struct {
+	entries []SummaryEntry
+	mu      sync.Mutex
+	next    int
+	wrapped bool
+}
+
+// NewSummary returns a [*Summary] retaining up to size entries.
+func NewSummary(size int) *Summary {
+	return &Summary{entries: make([]SummaryEntry, size)}
+}
+
+// record adds a completed transfer to the ring buffer.
+func (s *Summary) record(req *http.Request, size, count int64, elapsed time.Duration, samples []RampSample) {
+	loc := geoip.FromContext(req.Context())
+	s.add(SummaryEntry{
+		Time:     time.Now(),
+		Method:   req.Method,
+		Size:     size,
+		Bytes:    count,
+		Duration: elapsed.Seconds(),
+		Mbps:     Speed(count, elapsed) / 1e6,
+		Remote:   req.RemoteAddr,
+		ALPN:     TLSALPN(req),
+		Session:  req.Header.Get(sessionIDHeader),
+		Country:  loc.Country,
+		ASN:      loc.ASN,
+		Samples:  samples,
+	})
+}
+
+// recordProbe adds a GET /api/ping?probe=1 served in elapsed.
+func (s *Summary) recordProbe(req *http.Request, elapsed time.Duration) {
+	s.add(SummaryEntry{
+		Time:     time.Now(),
+		Method:   req.Method,
+		Size:     int64(len(pingBody)),
+		Bytes:    int64(len(pingBody)),
+		Duration: elapsed.Seconds(),
+		Remote:   req.RemoteAddr,
+		ALPN:     TLSALPN(req),
+		Session:  req.Header.Get(sessionIDHeader),
+		Probe:    true,
+	})
+}
+
+// add adds the given entry to the ring buffer.
+func (s *Summary) add(entry SummaryEntry) {
+	if s == nil || len(s.entries) <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[s.next] = entry
+	s.next = (s.next + 1) % len(s.entries)
+	s.wrapped = s.wrapped || s.next == 0
+}
+
+// snapshot returns the recorded entries from the oldest to the newest.
+func (s *Summary) snapshot() []SummaryEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.wrapped {
+		return append([]SummaryEntry{}, s.entries[:s.next]...)
+	}
+	return append(append([]SummaryEntry{}, s.entries[s.next:]...), s.entries[:s.next]...)
+}
+
+// HandleSummary handles GET /summary by writing the recent transfers as JSON.
+func (s *Summary) HandleSummary(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(rw).Encode(s.snapshot())
+}
@@ -0,0 +1,29 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// wantsTrailers returns whether the client requested the measurement
+// trailers using ?trailers=1, which requires a chunked GET response.
+func wantsTrailers(req *http.Request) bool {
+	return req.URL.Query().Get("trailers") == "1"
+}
+
+// announceTrailers declares the measurement trailers, which we must
+// do before writing the response headers.
+func announceTrailers(rw http.ResponseWriter) {
+	rw.Header().Set("Trailer", "Server-Timing, X-Bytes-Sent")
+}
+
+// setTrailers sets the measurement trailers after the body.
+func setTrailers(rw http.ResponseWriter, wireBytes int64, elapsed time.Duration) {
+	millis := float64(elapsed) / float64(time.Millisecond)
+	rw.Header().Set("Server-Timing", fmt.Sprintf("transfer;dur=%.3f", millis))
+	rw.Header().Set("X-Bytes-Sent", strconv.FormatInt(wireBytes, 10))
+}
@@ -0,0 +1,573 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package transfer implements the /api/{size} byte-transfer endpoints
+// shared by the measurement servers.
+package transfer
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bassosimone/2026-02-js-perf/internal/geoip"
+	"github.com/bassosimone/2026-02-js-perf/internal/humanize"
+	"github.com/bassosimone/2026-02-js-perf/internal/infinite"
+	"github.com/bassosimone/2026-02-js-perf/internal/sockopt"
+)
+
+// Handler implements the /api/{size} endpoints.
+//
+// The zero value is ready to use and serves zero-filled GET bodies.
+type Handler struct {
+	// AllowCompression enables Accept-Encoding negotiation for GET.
+	AllowCompression bool
+
+	// BodyReadTimeout is the maximum time PUT waits for the client to send
+	// more body bytes before failing with 408; zero means unlimited.
+	BodyReadTimeout time.Duration
+
+	// Chunked causes GET to omit Content-Length and use chunked encoding.
+	Chunked bool
+
+	// CloseAfter causes the server to close the connection after each
+	// GET and PUT response, as clients may request using ?close=1.
+	CloseAfter bool
+
+	// ContentType is the GET response Content-Type, which clients may
+	// override using ?type=; empty means [DefaultContentType].
+	ContentType string
+
+	// CopyBufferSize is the size of the GET and PUT copy buffers; zero
+	// means [DefaultCopyBufferSize].
+	CopyBufferSize int
+
+	// ErrorRate is the fraction of GET /api/{size} requests that fail
+	// with 500 before sending any body; zero disables error injection.
+	ErrorRate float64
+
+	// FillReader returns the reader producing GET bodies and the stream
+	// against which PUT ?verify=1 checks uploads, starting at the given
+	// offset of the stream; nil means zero-filled.
+	FillReader func(offset int64) io.Reader
+
+	// FirstByteDelay is the delay before sending the GET response headers.
+	FirstByteDelay time.Duration
+
+	// FlushInterval causes GET to flush the response after every
+	// FlushInterval bytes, such that HTTP/2 emits smaller DATA frames;
+	// zero means letting net/http decide when to flush.
+	FlushInterval int64
+
+	// Histograms accumulates transfer durations and sizes or is nil when disabled.
+	Histograms *Histograms
+
+	// MaxDuration is the maximum duration honored by GET /api/duration/{seconds};
+	// zero means unlimited.
+	MaxDuration time.Duration
+
+	// MaxSize is the maximum size honored by GET and PUT; zero means unlimited.
+	MaxSize int64
+
+	// Metrics collects metrics or is nil when disabled.
+	Metrics *Metrics
+
+	// RejectOversizeEarly causes PUT to reject bodies whose declared
+	// Content-Length exceeds the requested size before reading them.
+	RejectOversizeEarly bool
+
+	// ResponseDelay is the delay before serving GET and PUT requests.
+	ResponseDelay time.Duration
+
+	// Results writes a record per completed transfer or is nil when disabled.
+	Results *Results
+
+	// ServeFile is the path of a file whose first bytes GET sends rather
+	// than synthetic bytes, such that net/http may use sendfile; empty
+	// means using FillReader.
+	ServeFile string
+
+	// Sessions aggregates transfers by X-Session-ID or is nil when disabled.
+	Sessions *Sessions
+
+	// StrictSize causes PUT to fail with 400 when the bytes read differ
+	// from the requested size or from the declared Content-Length.
+	StrictSize bool
+
+	// Summary records completed transfers or is nil when disabled.
+	Summary *Summary
+
+	// Uploads tracks resumable uploads or is nil when disabled.
+	Uploads *Uploads
+
+	// buffers contains the copy buffers, which we reuse across requests
+	// to avoid allocating CopyBufferSize bytes for each of them.
+	buffers sync.Pool
+}
+
+// DefaultCopyBufferSize is the default size of the copy buffers.
+const DefaultCopyBufferSize = 1 << 20 // 1 MiB
+
+// DefaultContentType is the default GET response Content-Type.
+const DefaultContentType = "application/octet-stream"
+
+// contentType returns the Content-Type to use for the GET response.
+func (h *Handler) contentType(req *http.Request) (string, error) {
+	value := cmp.Or(req.URL.Query().Get("type"), h.ContentType, DefaultContentType)
+	if _, _, err := mime.ParseMediaType(value); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// setConnectionClose asks net/http to close the connection after the response.
+func (h *Handler) setConnectionClose(rw http.ResponseWriter, req *http.Request, logger *slog.Logger) {
+	if !h.CloseAfter && req.URL.Query().Get("close") != "1" {
+		return
+	}
+	// With HTTP/2, net/http sends GOAWAY rather than the header.
+	rw.Header().Set("Connection", "close")
+	logger.Info("closing conn by policy", slog.String("remote", req.RemoteAddr))
+}
+
+// getCopyBuffer returns a buffer for copying bodies, which the caller
+// must return using [*Handler.putCopyBuffer] once done with it.
+func (h *Handler) getCopyBuffer() *[]byte {
+	if buf, ok := h.buffers.Get().(*[]byte); ok {
+		return buf
+	}
+	buf := make([]byte, cmp.Or(max(h.CopyBufferSize, 0), DefaultCopyBufferSize))
+	return &buf
+}
+
+// putCopyBuffer returns a buffer obtained using [*Handler.getCopyBuffer].
+func (h *Handler) putCopyBuffer(buf *[]byte) {
+	h.buffers.Put(buf)
+}
+
+// exceedsMaxSize returns whether size exceeds the configured MaxSize.
+func (h *Handler) exceedsMaxSize(size int64) bool {
+	return h.MaxSize > 0 && size > h.MaxSize
+}
+
+// sleep waits for the given delay or until ctx is done.
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// TLSALPN returns the negotiated ALPN protocol, if any.
+func TLSALPN(req *http.Request) string {
+	if req.TLS != nil {
+		return req.TLS.NegotiatedProtocol
+	}
+	return ""
+}
+
+// TLSVersionAndCipher returns the negotiated TLS version and cipher suite, if any.
+func TLSVersionAndCipher(req *http.Request) (version, cipher string) {
+	if req.TLS != nil {
+		return tls.VersionName(req.TLS.Version), tls.CipherSuiteName(req.TLS.CipherSuite)
+	}
+	return "", ""
+}
+
+// TLSClientSubject returns the subject of the verified client certificate, if any.
+func TLSClientSubject(req *http.Request) string {
+	if req.TLS != nil && len(req.TLS.PeerCertificates) > 0 {
+		return req.TLS.PeerCertificates[0].Subject.String()
+	}
+	return ""
+}
+
+// errInvalidFillMode indicates that the fill mode is not supported.
+var errInvalidFillMode = errors.New("invalid fill mode")
+
+// FillModes contains the fill modes supported by [NewFillFunc].
+var FillModes = []string{"zero", "pattern", "random", "offset"}
+
+// NewFillFunc returns a [Handler.FillReader] for the given fill mode.
+func NewFillFunc(mode string, seed uint64) (func(offset int64) io.Reader, error) {
+	switch mode {
+	case "zero":
+		return func(int64) io.Reader { return infinite.Reader{} }, nil
+	case "pattern":
+		return func(offset int64) io.Reader { return infinite.NewPatternReader(uint64(offset)) }, nil
+	case "random":
+		return func(offset int64) io.Reader { return infinite.NewRandomReaderAt(seed, uint64(offset)) }, nil
+	case "offset":
+		return func(offset int64) io.Reader { return infinite.NewOffsetReader(uint64(offset)) }, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", errInvalidFillMode, mode)
+	}
+}
+
+// newFillReader returns a new reader for the configured fill starting
+// at the given offset of the stream.
+func (h *Handler) newFillReader(offset int64) io.Reader {
+	if h.FillReader == nil {
+		return infinite.Reader{}
+	}
+	return h.FillReader(offset)
+}
+
+// setServerTiming adds a Server-Timing header entry for the given metric.
+func setServerTiming(rw http.ResponseWriter, metric string, elapsed time.Duration) {
+	millis := float64(elapsed) / float64(time.Millisecond)
+	rw.Header().Add("Server-Timing", fmt.Sprintf("%s;dur=%.3f", metric, millis))
+}
+
+// Speed returns the speed in bit/s of transferring count bytes in elapsed.
+func Speed(count int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(count) * 8 / elapsed.Seconds()
+}
+
+// mibps returns the speed in MiB/s of transferring count bytes in elapsed.
+func mibps(count int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(count) / (1 << 20) / elapsed.Seconds()
+}
+
+// HandleGet handles GET /api/{size} by sending size bytes, or an
+// infinite stream when size is "infinite".
+func (h *Handler) HandleGet(rw http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+	logger := slog.With(slog.String("reqid", requestID(rw, req)))
+	size := requestSize(req)
+	if size == infiniteSize {
+		// An infinite body exceeds any configured maximum size.
+		if h.MaxSize > 0 {
+			h.Metrics.observe(http.MethodGet, http.StatusBadRequest, 0, 0)
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		h.handleGetStream(rw, req, logger, 0)
+		return
+	}
+	count, err := ParseSize(size)
+	if err != nil || h.exceedsMaxSize(count) {
+		h.Metrics.observe(http.MethodGet, http.StatusBadRequest, 0, 0)
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	contentType, err := h.contentType(req)
+	if err != nil {
+		h.Metrics.observe(http.MethodGet, http.StatusBadRequest, 0, 0)
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	ramp, err := rampInterval(req)
+	if err != nil {
+		h.Metrics.observe(http.MethodGet, http.StatusBadRequest, 0, 0)
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if h.injectError(rw, req, logger) {
+		return
+	}
+	var serveFile *os.File
+	if h.ServeFile != "" {
+		serveFile, err = h.openServeFile(count)
+		if err != nil {
+			logger.Warn("GET cannot serve file", slog.Any("err", err), slog.String("remote", req.RemoteAddr))
+			h.Metrics.observe(http.MethodGet, http.StatusBadRequest, 0, 0)
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer serveFile.Close()
+	}
+	tlsVersion, tlsCipher := TLSVersionAndCipher(req)
+	logger.Info("GET",
+		slog.Int64("count", count),
+		slog.String("proto", req.Proto),
+		slog.String("alpn", TLSALPN(req)),
+		slog.String("tlsVersion", tlsVersion),
+		slog.String("tlsCipher", tlsCipher),
+		slog.Bool("tlsResumed", req.TLS != nil && req.TLS.DidResume),
+		slog.String("client", TLSClientSubject(req)),
+		slog.String("remote", req.RemoteAddr),
+		geoip.FromContext(req.Context()).Attr(),
+	)
+	h.setConnectionClose(rw, req, logger)
+	rw.Header().Set("Accept-Ranges", "bytes")
+	rw.Header().Set("Content-Type", contentType)
+
+	// Ranges address the identity representation, so a range
+	// request disables content-coding negotiation.
+	status, offset, length := http.StatusOK, int64(0), count
+	var encoding string
+	if value := req.Header.Get("Range"); value != "" {
+		var size int64
+		offset, size, err = parseRange(value, count)
+		if err != nil {
+			h.Metrics.observe(http.MethodGet, http.StatusRequestedRangeNotSatisfiable, 0, 0)
+			rw.Header().Set("Content-Range", "bytes */"+strconv.FormatInt(count, 10))
+			rw.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		rw.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+size-1, count))
+		status, length = http.StatusPartialContent, size
+	} else if h.AllowCompression {
+		var acceptable bool
+		encoding, acceptable = negotiateEncoding(req.Header.Get("Accept-Encoding"))
+		rw.Header().Add("Vary", "Accept-Encoding")
+		if !acceptable {
+			h.Metrics.observe(http.MethodGet, http.StatusNotAcceptable, 0, 0)
+			rw.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+	}
+
+	// Only the zero fill writes from a shared buffer, while copying the
+	// other fills through [*infinite.LimitedReader.WriteTo] would allocate
+	// a new buffer for each request instead of using the pooled one.
+	fill := h.newFillReader(offset)
+	var bodyReader io.Reader = infinite.LimitReader(fill, length)
+	if _, zero := fill.(infinite.Reader); !zero {
+		bodyReader = io.LimitReader(fill, length)
+	}
+	if serveFile != nil {
+		if bodyReader, err = newServeFileReader(serveFile, offset, length); err != nil {
+			logger.Warn("GET cannot serve file", slog.Any("err", err), slog.String("remote", req.RemoteAddr))
+			h.Metrics.observe(http.MethodGet, http.StatusInternalServerError, 0, 0)
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := sleep(req.Context(), h.ResponseDelay+h.FirstByteDelay); err != nil {
+		logger.Info("GET interrupted", slog.Any("err", err), slog.String("remote", req.RemoteAddr))
+		return
+	}
+
+	// The transfer duration is not known before writing the headers, so
+	// we can only report how long the server took to start the response.
+	stream := h.Sessions.begin(req)
+	t0 := time.Now()
+	setServerTiming(rw, "setup", t0.Sub(start))
+	buf := h.getCopyBuffer()
+	defer h.putCopyBuffer(buf)
+	out := h.Metrics.liveWriter(newFlushWriter(rw, h.FlushInterval))
+	if limiter := contextLimiter(req.Context()); limiter != nil {
+		out = &rateWriter{ctx: req.Context(), limiter: limiter, w: out}
+	}
+	sampler := newRampSampler(ramp)
+	out = sampler.wrap(out)
+	var (
+		written, wireBytes int64
+		copyErr            error
+	)
+	trailers := wantsTrailers(req)
+	if trailers {
+		announceTrailers(rw)
+	}
+	chunked := h.Chunked || req.URL.Query().Get("chunked") == "1" || trailers
+	if encoding == "" {
+		if !chunked {
+			rw.Header().Set("Content-Length", strconv.FormatInt(length, 10))
+		}
+		rw.WriteHeader(status)
+		if chunked {
+			// Flushing the headers forces chunked encoding, otherwise net/http
+			// would compute the Content-Length of small bodies on its own.
+			http.NewResponseController(rw).Flush()
+		}
+		written, copyErr = io.CopyBuffer(out, bodyReader, *buf)
+		wireBytes = written
+	} else {
+		// Omitting Content-Length makes net/http use chunked encoding.
+		rw.Header().Set("Content-Encoding", encoding)
+		rw.WriteHeader(status)
+		wire := &countingWriter{w: out}
+		encoder := newEncoder(wire, encoding)
+		written, copyErr = io.CopyBuffer(encoder, bodyReader, *buf)
+		encoder.Close() // flush the trailer, which is required even when count is zero
+		wireBytes = wire.count
+	}
+	elapsed := time.Since(t0)
+	samples := sampler.finish()
+	if trailers {
+		setTrailers(rw, wireBytes, elapsed)
+	}
+	logger.Info("GET done",
+		slog.Int64("bytes", written),
+		slog.Int64("wireBytes", wireBytes),
+		slog.String("encoding", encoding),
+		copyOutcomeAttrs(req.Context(), copyErr),
+		slog.Duration("elapsed", elapsed),
+		slog.String("speed", humanize.SI(Speed(wireBytes, elapsed), "bit/s")),
+		slog.Float64("mbps", Speed(wireBytes, elapsed)/1e6),
+		slog.Float64("mibps", mibps(wireBytes, elapsed)),
+		slog.String("remote", req.RemoteAddr),
+		sockopt.TCPInfoAttr(contextConn(req.Context())),
+	)
+	h.Metrics.observe(http.MethodGet, status, wireBytes, elapsed)
+	h.Summary.record(req, count, wireBytes, elapsed, samples)
+	h.Histograms.record(req.Method, wireBytes, elapsed)
+	h.Results.record(rw, req, count, wireBytes, elapsed)
+	setSpanAttributes(req, count, wireBytes, elapsed)
+	stream.end(wireBytes)
+}
+
+// HandlePut handles PUT (or POST) /api/{size} by reading and discarding size bytes.
+func (h *Handler) HandlePut(rw http.ResponseWriter, req *http.Request) {
+	logger := slog.With(slog.String("reqid", requestID(rw, req)))
+	expectCount, err := ParseSize(requestSize(req))
+	if err != nil {
+		h.Metrics.observe(req.Method, http.StatusBadRequest, 0, 0)
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	verifyStart, err := verifyOffset(req)
+	if err != nil {
+		h.Metrics.observe(req.Method, http.StatusBadRequest, 0, 0)
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// Because expectCount bounds the body reader, rejecting oversized
+	// requests also ensures we never read more than MaxSize bytes.
+	if h.exceedsMaxSize(expectCount) {
+		h.Metrics.observe(req.Method, http.StatusRequestEntityTooLarge, 0, 0)
+		rw.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// The net/http server sends 100 Continue to clients using Expect only
+	// when we first read the body, so rejecting here saves the upload.
+	if h.RejectOversizeEarly && req.ContentLength > expectCount {
+		logger.Info("PUT rejected early",
+			slog.Int64("expectCount", expectCount),
+			slog.Int64("contentLength", req.ContentLength),
+			slog.String("remote", req.RemoteAddr),
+		)
+		h.Metrics.observe(req.Method, http.StatusRequestEntityTooLarge, 0, 0)
+		rw.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+	tlsVersion, tlsCipher := TLSVersionAndCipher(req)
+	logger.Info("PUT",
+		slog.Int64("expectCount", expectCount),
+		slog.String("proto", req.Proto),
+		slog.String("alpn", TLSALPN(req)),
+		slog.String("tlsVersion", tlsVersion),
+		slog.String("tlsCipher", tlsCipher),
+		slog.Bool("tlsResumed", req.TLS != nil && req.TLS.DidResume),
+		slog.String("client", TLSClientSubject(req)),
+		slog.String("remote", req.RemoteAddr),
+		geoip.FromContext(req.Context()).Attr(),
+	)
+	h.setConnectionClose(rw, req, logger)
+	if err := sleep(req.Context(), h.ResponseDelay); err != nil {
+		logger.Info("PUT interrupted", slog.Any("err", err), slog.String("remote", req.RemoteAddr))
+		return
+	}
+	stream := h.Sessions.begin(req)
+	t0 := time.Now()
+	body := h.newDeadlineReader(rw, req)
+	bodyReader := h.Metrics.liveReader(io.LimitReader(body, expectCount))
+	buf := h.getCopyBuffer()
+	defer h.putCopyBuffer(buf)
+	var (
+		sink     io.Writer = io.Discard
+		verifier *verifyWriter
+	)
+	if req.URL.Query().Get("verify") == "1" {
+		verifier = &verifyWriter{expected: h.newFillReader(verifyStart)}
+		sink = verifier
+	}
+	var digest hash.Hash
+	if wantsSHA256(req) {
+		digest = sha256.New()
+		sink = io.MultiWriter(sink, digest)
+	}
+	read, err := io.CopyBuffer(sink, bodyReader, *buf)
+	elapsed := time.Since(t0)
+	stream.end(read)
+	if verifier != nil && errors.Is(err, errVerifyMismatch) {
+		logger.Info("PUT verify failed",
+			slog.Int64("offset", verifier.offset),
+			slog.String("remote", req.RemoteAddr),
+		)
+		h.Metrics.observe(req.Method, http.StatusBadRequest, read, elapsed)
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		logger.Info("PUT timeout",
+			slog.Int64("bytes", read),
+			slog.Duration("timeout", h.BodyReadTimeout),
+			slog.String("remote", req.RemoteAddr),
+		)
+		h.Metrics.observe(req.Method, http.StatusRequestTimeout, read, elapsed)
+		rw.Header().Set("Connection", "close")
+		rw.WriteHeader(http.StatusRequestTimeout)
+		return
+	}
+	// The body reader stops at expectCount, so a longer body shows up
+	// as a Content-Length larger than the bytes we have read or, when the
+	// length is unknown (e.g., chunked), as a byte past expectCount.
+	mismatch := read != expectCount || (req.ContentLength >= 0 && req.ContentLength != read)
+	if !mismatch && err == nil && req.ContentLength < 0 {
+		var extra [1]byte
+		count, _ := io.ReadFull(body, extra[:])
+		mismatch = count > 0
+	}
+	logger.Info("PUT done",
+		slog.Int64("bytes", read),
+		slog.Int64("expectCount", expectCount),
+		slog.Int64("contentLength", req.ContentLength),
+		slog.Bool("mismatch", mismatch),
+		copyOutcomeAttrs(req.Context(), err),
+		slog.Duration("elapsed", elapsed),
+		slog.Float64("mbps", Speed(read, elapsed)/1e6),
+		slog.Float64("mibps", mibps(read, elapsed)),
+		slog.String("remote", req.RemoteAddr),
+		sockopt.TCPInfoAttr(contextConn(req.Context())),
+	)
+	if mismatch && h.StrictSize {
+		h.Metrics.observe(req.Method, http.StatusBadRequest, read, elapsed)
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	status := http.StatusNoContent
+	if digest != nil {
+		value := base64.StdEncoding.EncodeToString(digest.Sum(nil))
+		rw.Header().Set("Repr-Digest", "sha-256=:"+value+":")
+		rw.Header().Set("Digest", "SHA-256="+value)
+		status = http.StatusOK
+	}
+	h.Metrics.observe(req.Method, status, read, elapsed)
+	h.Summary.record(req, expectCount, read, elapsed, nil)
+	h.Histograms.record(req.Method, read, elapsed)
+	h.Results.record(rw, req, expectCount, read, elapsed)
+	setSpanAttributes(req, expectCount, read, elapsed)
+	setServerTiming(rw, "transfer", elapsed)
+	rw.WriteHeader(status)
+}
@@ -0,0 +1,238 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+	os.Exit(m.Run())
+}
+
+func TestHandleGetRangeOffsetFill(t *testing.T) {
+	fill, err := NewFillFunc("offset", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &Handler{FillReader: fill}
+
+	cases := []struct {
+		rangeHeader  string
+		contentRange string
+		length       int
+	}{
+		{"bytes=0-15", "bytes 0-15/1024", 16},
+		{"bytes=64-127", "bytes 64-127/1024", 64},
+		{"bytes=13-40", "bytes 13-40/1024", 28},
+		{"bytes=-5", "bytes 1019-1023/1024", 5},
+	}
+	for _, tc := range cases {
+		t.Run(tc.rangeHeader, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/1024", nil)
+			req.SetPathValue("size", "1024")
+			req.Header.Set("Range", tc.rangeHeader)
+			rr := httptest.NewRecorder()
+			h.HandleGet(rr, req)
+
+			if rr.Code != http.StatusPartialContent {
+				t.Fatalf("status: got %d, want %d", rr.Code, http.StatusPartialContent)
+			}
+			if got := rr.Header().Get("Content-Range"); got != tc.contentRange {
+				t.Fatalf("Content-Range: got %q, want %q", got, tc.contentRange)
+			}
+			var start, end, total uint64
+			if _, err := fmt.Sscanf(rr.Header().Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &total); err != nil {
+				t.Fatal(err)
+			}
+			body, err := io.ReadAll(rr.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(body) != tc.length {
+				t.Fatalf("length: got %d, want %d", len(body), tc.length)
+			}
+			for idx, value := range body {
+				offset := start + uint64(idx)
+				var word [8]byte
+				binary.LittleEndian.PutUint64(word[:], offset-offset%8)
+				if value != word[offset%8] {
+					t.Fatalf("byte at offset %d: got %#x, want %#x", offset, value, word[offset%8])
+				}
+			}
+		})
+	}
+}
+
+func TestHandleGetRangeFillModes(t *testing.T) {
+	for _, mode := range []string{"zero", "pattern", "random", "offset"} {
+		t.Run(mode, func(t *testing.T) {
+			fill, err := NewFillFunc(mode, 42)
+			if err != nil {
+				t.Fatal(err)
+			}
+			h := &Handler{FillReader: fill}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/1024", nil)
+			req.SetPathValue("size", "1024")
+			rr := httptest.NewRecorder()
+			h.HandleGet(rr, req)
+			full := rr.Body.Bytes()
+
+			for _, bounds := range [][2]int{{0, 7}, {3, 300}, {255, 257}, {517, 1023}} {
+				req := httptest.NewRequest(http.MethodGet, "/api/1024", nil)
+				req.SetPathValue("size", "1024")
+				req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", bounds[0], bounds[1]))
+				rr := httptest.NewRecorder()
+				h.HandleGet(rr, req)
+				if want := full[bounds[0] : bounds[1]+1]; !bytes.Equal(rr.Body.Bytes(), want) {
+					t.Fatalf("range %v: body does not match the full body", bounds)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlePutVerifyRange(t *testing.T) {
+	fill, err := NewFillFunc("random", 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &Handler{FillReader: fill}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/1024", nil)
+	req.SetPathValue("size", "1024")
+	req.Header.Set("Range", "bytes=100-599")
+	rr := httptest.NewRecorder()
+	h.HandleGet(rr, req)
+	body, contentRange := rr.Body.Bytes(), rr.Header().Get("Content-Range")
+
+	cases := []struct {
+		name         string
+		contentRange string
+		status       int
+	}{
+		{"with Content-Range", contentRange, http.StatusNoContent},
+		{"without Content-Range", "", http.StatusBadRequest},
+		{"wrong Content-Range", "bytes 101-600/1024", http.StatusBadRequest},
+		{"invalid Content-Range", "bytes 600-100/1024", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/500?verify=1", bytes.NewReader(body))
+			req.SetPathValue("size", "500")
+			if tc.contentRange != "" {
+				req.Header.Set("Content-Range", tc.contentRange)
+			}
+			rr := httptest.NewRecorder()
+			h.HandlePut(rr, req)
+			if rr.Code != tc.status {
+				t.Fatalf("status: got %d, want %d", rr.Code, tc.status)
+			}
+		})
+	}
+}
+
+func TestHandlePutStrictSize(t *testing.T) {
+	cases := []struct {
+		name          string
+		bodySize      int
+		contentLength int64
+		status        int
+	}{
+		{"exact", 500, 500, http.StatusNoContent},
+		{"short", 400, 400, http.StatusBadRequest},
+		{"long", 600, 600, http.StatusBadRequest},
+		{"exact chunked", 500, -1, http.StatusNoContent},
+		{"short chunked", 400, -1, http.StatusBadRequest},
+		{"long chunked", 600, -1, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := &Handler{StrictSize: true}
+			req := httptest.NewRequest(http.MethodPut, "/api/500", bytes.NewReader(make([]byte, tc.bodySize)))
+			req.SetPathValue("size", "500")
+			req.ContentLength = tc.contentLength
+			rr := httptest.NewRecorder()
+			h.HandlePut(rr, req)
+			if rr.Code != tc.status {
+				t.Fatalf("status: got %d, want %d", rr.Code, tc.status)
+			}
+		})
+	}
+}
+
+func TestHandlePutRejectOversizeEarly(t *testing.T) {
+	h := &Handler{RejectOversizeEarly: true}
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /api/{size}", h.HandlePut)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// We use a raw connection because net/http clients hide interim responses.
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	request := "PUT /api/1000 HTTP/1.1\r\n" +
+		"Host: " + srv.Listener.Addr().String() + "\r\n" +
+		"Expect: 100-continue\r\n" +
+		"Content-Length: 1000000\r\n\r\n"
+	if _, err := io.WriteString(conn, request); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first response must be the final one, without any 100 Continue.
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status: got %d, want %d", resp.StatusCode, http.StatusRequestEntityTooLarge)
+	}
+}
+
+// discardResponseWriter is an [http.ResponseWriter] discarding the body.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (rw *discardResponseWriter) Header() http.Header { return rw.header }
+
+func (rw *discardResponseWriter) Write(data []byte) (int, error) { return len(data), nil }
+
+func (rw *discardResponseWriter) WriteHeader(int) {}
+
+func BenchmarkHandleGet(b *testing.B) {
+	const size = 1 << 20
+	for _, mode := range []string{"zero", "pattern", "random", "offset"} {
+		b.Run(mode, func(b *testing.B) {
+			fill, err := NewFillFunc(mode, 42)
+			if err != nil {
+				b.Fatal(err)
+			}
+			h := &Handler{FillReader: fill}
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/%d", size), nil)
+			req.SetPathValue("size", strconv.Itoa(size))
+			b.SetBytes(size)
+			b.ReportAllocs()
+			for b.Loop() {
+				h.HandleGet(&discardResponseWriter{header: http.Header{}}, req)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bassosimone/2026-02-js-perf/internal/geoip"
+)
+
+// uploadOffsetHeader carries the number of bytes received for an upload.
+const uploadOffsetHeader = "Upload-Offset"
+
+// maxUploadIDLength bounds the length of the upload ID we accept.
+const maxUploadIDLength = 128
+
+// Uploads tracks the bytes received by resumable uploads.
+//
+// Construct using [NewUploads]. A nil [*Uploads] is valid.
+type Uploads struct {
+	max     int
+	mu      sync.Mutex
+	ttl     time.Duration
+	uploads map[string]*upload
+}
+
+// upload contains the state of a resumable upload.
+type upload struct {
+	active bool
+	last   time.Time
+	offset int64
+	total  int64 // -1 when unknown
+}
+
+// NewUploads returns a [*Uploads] tracking at most max uploads, each
+// forgotten after not receiving bytes for ttl.
+func NewUploads(max int, ttl time.Duration) *Uploads {
+	return &Uploads{max: max, ttl: ttl, uploads: map[string]*upload{}}
+}
+
+// errUploadConflict indicates that a chunk does not start at the
+// current offset or that another chunk is being uploaded.
+var errUploadConflict = errors.New("upload conflict")
+
+// errUploadsFull indicates that we are tracking too many uploads.
+var errUploadsFull = errors.New("too many uploads")
+
+// errUploadTotalMismatch indicates a chunk declaring a different total.
+var errUploadTotalMismatch = errors.New("upload total mismatch")
+
+// expire forgets the uploads idle for longer than the TTL. The caller
+// must hold the mutex.
+func (u *Uploads) expire(now time.Time) {
+	for id, up := range u.uploads {
+		if !up.active && now.Sub(up.last) > u.ttl {
+			delete(u.uploads, id)
+		}
+	}
+}
+
+// offset returns the bytes received for the given upload.
+func (u *Uploads) offset(id string) (int64, bool) {
+	if u == nil {
+		return 0, false
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.expire(time.Now())
+	up := u.uploads[id]
+	if up == nil {
+		return 0, false
+	}
+	return up.offset, true
+}
+
+// begin marks the upload active for a chunk starting at start.
+func (u *Uploads) begin(id string, start, total int64) (int64, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	now := time.Now()
+	u.expire(now)
+	up := u.uploads[id]
+	if up == nil {
+		if start != 0 {
+			return 0, errUploadConflict
+		}
+		if len(u.uploads) >= u.max {
+			return 0, errUploadsFull
+		}
+		up = &upload{total: total}
+		u.uploads[id] = up
+	}
+	if up.active || start != up.offset {
+		return up.offset, errUploadConflict
+	}
+	if up.total >= 0 && total >= 0 && up.total != total {
+		return up.offset, errUploadTotalMismatch
+	}
+	up.total = max(up.total, total)
+	up.active = true
+	up.last = now
+	return up.offset, nil
+}
+
+// end marks the upload inactive after receiving count more bytes and
+// returns the new offset and whether the upload is complete.
+func (u *Uploads) end(id string, count int64) (int64, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	up := u.uploads[id]
+	up.active = false
+	up.last = time.Now()
+	up.offset += count
+	return up.offset, up.total >= 0 && up.offset >= up.total
+}
+
+// parseContentRange parses a `bytes first-last/total` Content-Range value.
+func parseContentRange(header string) (start, length, total int64, err error) {
+	spec, found := strings.CutPrefix(header, "bytes ")
+	if !found {
+		return 0, 0, 0, errRangeNotSatisfiable
+	}
+	span, size, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, 0, errRangeNotSatisfiable
+	}
+	first, last, found := strings.Cut(span, "-")
+	if !found {
+		return 0, 0, 0, errRangeNotSatisfiable
+	}
+	start, err = strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, 0, errRangeNotSatisfiable
+	}
+	end, err := strconv.ParseInt(last, 10, 64)
+	if err != nil || end < start {
+		return 0, 0, 0, errRangeNotSatisfiable
+	}
+	total = -1
+	if size != "*" {
+		total, err = strconv.ParseInt(size, 10, 64)
+		if err != nil || end >= total {
+			return 0, 0, 0, errRangeNotSatisfiable
+		}
+	}
+	return start, end - start + 1, total, nil
+}
+
+// HandleUploadHead handles HEAD /api/upload/{id} by returning the bytes
+// received so far in the Upload-Offset header, or 404 when unknown.
+func (h *Handler) HandleUploadHead(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Cache-Control", "no-store")
+	offset, found := h.Uploads.offset(req.PathValue("id"))
+	if !found {
+		rw.WriteHeader(http.StatusNotFound)
+		return
+	}
+	rw.Header().Set(uploadOffsetHeader, strconv.FormatInt(offset, 10))
+	rw.WriteHeader(http.StatusOK)
+}
+
+// HandleUploadPut handles PUT /api/upload/{id} by receiving a chunk.
+func (h *Handler) HandleUploadPut(rw http.ResponseWriter, req *http.Request) {
+	logger := slog.With(slog.String("reqid", requestID(rw, req)))
+	rw.Header().Set("Cache-Control", "no-store")
+	id := req.PathValue("id")
+	if h.Uploads == nil || id == "" || len(id) > maxUploadIDLength {
+		h.Metrics.observe(http.MethodPut, http.StatusNotFound, 0, 0)
+		rw.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	start, length, total := int64(0), req.ContentLength, req.ContentLength
+	if header := req.Header.Get("Content-Range"); header != "" {
+		var err error
+		start, length, total, err = parseContentRange(header)
+		if err != nil || (req.ContentLength >= 0 && req.ContentLength != length) {
+			h.Metrics.observe(http.MethodPut, http.StatusBadRequest, 0, 0)
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+	if length < 0 {
+		h.Metrics.observe(http.MethodPut, http.StatusLengthRequired, 0, 0)
+		rw.WriteHeader(http.StatusLengthRequired)
+		return
+	}
+	if h.exceedsMaxSize(start+length) || h.exceedsMaxSize(total) {
+		h.Metrics.observe(http.MethodPut, http.StatusRequestEntityTooLarge, 0, 0)
+		rw.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	offset, err := h.Uploads.begin(id, start, total)
+	switch {
+	case errors.Is(err, errUploadsFull):
+		h.Metrics.observe(http.MethodPut, http.StatusServiceUnavailable, 0, 0)
+		rw.Header().Set("Retry-After", "1")
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		logger.Info("upload conflict",
+			slog.String("upload", id),
+			slog.Int64("start", start),
+			slog.Int64("offset", offset),
+			slog.Any("err", err),
+			slog.String("remote", req.RemoteAddr),
+		)
+		h.Metrics.observe(http.MethodPut, http.StatusConflict, 0, 0)
+		rw.Header().Set(uploadOffsetHeader, strconv.FormatInt(offset, 10))
+		rw.WriteHeader(http.StatusConflict)
+		return
+	}
+	logger.Info("upload",
+		slog.String("upload", id),
+		slog.Int64("start", start),
+		slog.Int64("length", length),
+		slog.Int64("total", total),
+		slog.String("proto", req.Proto),
+		slog.String("remote", req.RemoteAddr),
+		geoip.FromContext(req.Context()).Attr(),
+	)
+
+	t0 := time.Now()
+	bodyReader := h.Metrics.liveReader(io.LimitReader(h.newDeadlineReader(rw, req), length))
+	buf := h.getCopyBuffer()
+	defer h.putCopyBuffer(buf)
+	read, err := io.CopyBuffer(io.Discard, bodyReader, *buf)
+	elapsed := time.Since(t0)
+	offset, complete := h.Uploads.end(id, read)
+	logger.Info("upload done",
+		slog.String("upload", id),
+		slog.Int64("bytes", read),
+		slog.Int64("offset", offset),
+		slog.Bool("complete", complete),
+		copyOutcomeAttrs(req.Context(), err),
+		slog.Duration("elapsed", elapsed),
+		slog.Float64("mbps", Speed(read, elapsed)/1e6),
+		slog.String("remote", req.RemoteAddr),
+	)
+	h.Metrics.observe(http.MethodPut, http.StatusNoContent, read, elapsed)
+	h.Summary.record(req, length, read, elapsed, nil)
+	h.Histograms.record(req.Method, read, elapsed)
+	h.Results.record(rw, req, length, read, elapsed)
+	setSpanAttributes(req, length, read, elapsed)
+	rw.Header().Set(uploadOffsetHeader, strconv.FormatInt(offset, 10))
+	rw.WriteHeader(http.StatusNoContent)
+}
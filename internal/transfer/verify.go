@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transfer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// errVerifyMismatch indicates that the body does not match the expected stream.
+var errVerifyMismatch = errors.New("body does not match the expected stream")
+
+// verifyOffset returns the offset at which a PUT ?verify=1 body starts.
+func verifyOffset(req *http.Request) (int64, error) {
+	header := req.Header.Get("Content-Range")
+	if req.URL.Query().Get("verify") != "1" || header == "" {
+		return 0, nil
+	}
+	start, _, _, err := parseContentRange(header)
+	return start, err
+}
+
+// verifyWriter is an [io.Writer] checking that the bytes written match
+// those returned by the expected reader and failing on the first mismatch.
+type verifyWriter struct {
+	buf      []byte
+	expected io.Reader
+	offset   int64
+}
+
+var _ io.Writer = &verifyWriter{}
+
+// Write implements [io.Writer].
+func (vw *verifyWriter) Write(data []byte) (int, error) {
+	if cap(vw.buf) < len(data) {
+		vw.buf = make([]byte, len(data))
+	}
+	want := vw.buf[:len(data)]
+	if _, err := io.ReadFull(vw.expected, want); err != nil {
+		return 0, err
+	}
+	if !bytes.Equal(data, want) {
+		idx := 0
+		for data[idx] == want[idx] {
+			idx++
+		}
+		vw.offset += int64(idx)
+		return idx, errVerifyMismatch
+	}
+	vw.offset += int64(len(data))
+	return len(data), nil
+}
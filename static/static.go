@@ -0,0 +1,12 @@
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package static embeds the browser measurement frontends, such that
+// servers can be distributed as a single binary.
+package static
+
+import "embed"
+
+// FS contains the http1, http2, and ndt7 frontends.
+//
+//go:embed http1 http2 ndt7
+var FS embed.FS